@@ -0,0 +1,160 @@
+package collection
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Aggregates summarize payloads of the points matching a filter without handing the payloads
+// out. Each call reads every matching payload under the read lock, so the result reflects one
+// consistent view of the collection.
+
+// FacetValue is one distinct value of a payload field and the number of points holding it
+type FacetValue struct {
+	// string, bool, int64 for whole numbers or float64
+	Value any
+	Count int
+}
+
+// NumericStats summarizes the numeric values of a payload field
+type NumericStats struct {
+	// points with a numeric value for the field, Min and Max are zero when it is 0
+	Count    int
+	Min, Max float64
+}
+
+// Count returns the number of points matching filter, nil counts every point
+func (c *Collection) Count(filter *Filter) (int, error) {
+	if err := filter.validate(); err != nil {
+		return 0, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if filter == nil {
+		return len(c.extToInt) - len(c.expiredIDs()), nil
+	}
+	count := 0
+	err := c.forEachPayload(filter, func(any) { count++ })
+	return count, err
+}
+
+// Facet counts the distinct values of a keyword like payload field (strings, bools, numbers)
+// over the points matching filter. A point counts once per distinct element of a list value.
+// Values are ordered by count, most frequent first, limit <= 0 returns all of them
+func (c *Collection) Facet(key string, filter *Filter, limit int) ([]FacetValue, error) {
+	if key == "" {
+		return nil, fmt.Errorf("%w: facet without key", ErrInvalidFilter)
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	counts := make(map[any]int)
+	err := c.forEachPayload(filter, func(payload any) {
+		val, ok := lookupKey(filterObject(payload), key)
+		if !ok {
+			return
+		}
+		values, isList := val.([]any)
+		if !isList {
+			values = []any{val}
+		}
+		seen := make(map[any]bool, len(values))
+		for _, v := range values {
+			fv, ok := facetKey(v)
+			if !ok || seen[fv] {
+				continue
+			}
+			seen[fv] = true
+			counts[fv]++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	facets := make([]FacetValue, 0, len(counts))
+	for v, n := range counts {
+		facets = append(facets, FacetValue{Value: v, Count: n})
+	}
+	// ties ordered by value so results are deterministic
+	slices.SortFunc(facets, func(a, b FacetValue) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(fmt.Sprint(a.Value), fmt.Sprint(b.Value))
+	})
+	if limit > 0 && limit < len(facets) {
+		facets = facets[:limit]
+	}
+	return facets, nil
+}
+
+// NumericStats returns count, min and max of the numeric values of a payload field over the
+// points matching filter, every element of a numeric list counts
+func (c *Collection) NumericStats(key string, filter *Filter) (NumericStats, error) {
+	if key == "" {
+		return NumericStats{}, fmt.Errorf("%w: stats without key", ErrInvalidFilter)
+	}
+	if err := filter.validate(); err != nil {
+		return NumericStats{}, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var stats NumericStats
+	err := c.forEachPayload(filter, func(payload any) {
+		val, ok := lookupKey(filterObject(payload), key)
+		if !ok {
+			return
+		}
+		values, isList := val.([]any)
+		if !isList {
+			values = []any{val}
+		}
+		for _, v := range values {
+			n, ok := toFloat(v)
+			if !ok {
+				continue
+			}
+			if stats.Count == 0 {
+				stats.Min, stats.Max = n, n
+			}
+			stats.Min, stats.Max = min(stats.Min, n), max(stats.Max, n)
+			stats.Count++
+		}
+	})
+	return stats, err
+}
+
+// calls fn with the payload of every point matching filter, caller holds c.mu
+func (c *Collection) forEachPayload(filter *Filter, fn func(payload any)) error {
+	for extID, internalID := range c.extToInt {
+		if c.expired(internalID) {
+			continue
+		}
+		payload, _, err := c.payloads.Get(extID)
+		if err != nil {
+			return fmt.Errorf("failed to read payload of %s: %w", extID, err)
+		}
+		if filter.matches(payload) {
+			fn(payload)
+		}
+	}
+	return nil
+}
+
+// comparable form of a facet value, numbers unified so 3, int64(3) and 3.0 are one value
+func facetKey(v any) (any, bool) {
+	switch v.(type) {
+	case string, bool:
+		return v, true
+	}
+	if i, ok := toInt(v); ok {
+		return i, true
+	}
+	if f, ok := toFloat(v); ok {
+		return f, true
+	}
+	return nil, false
+}
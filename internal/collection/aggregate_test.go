@@ -0,0 +1,60 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollection_CountAndAggregates(t *testing.T) {
+	c := scrollCollection(t)
+	payloads := []any{
+		map[string]any{"city": "Pune", "price": 10, "tags": []string{"a", "b", "a"}},
+		map[string]any{"city": "Pune", "price": 2.5},
+		map[string]any{"city": "Delhi", "price": 40, "tags": []string{"b"}},
+		map[string]any{"city": "Goa"},
+		"not an object",
+	}
+	var ids []string
+	for _, p := range payloads {
+		id, _ := c.Insert([]float32{1, 0}, p)
+		ids = append(ids, id)
+	}
+
+	if n, _ := c.Count(nil); n != 5 {
+		t.Errorf("expected 5 points, got %d", n)
+	}
+	if n, _ := c.Count(&Filter{Must: []Condition{{Key: "city", Match: "Pune"}}}); n != 2 {
+		t.Errorf("expected 2 points in Pune, got %d", n)
+	}
+
+	facets, err := c.Facet("city", nil, 0)
+	if err != nil {
+		t.Fatalf("Facet failed: %v", err)
+	}
+	want := []FacetValue{{"Pune", 2}, {"Delhi", 1}, {"Goa", 1}}
+	if !reflect.DeepEqual(facets, want) {
+		t.Errorf("expected %v, got %v", want, facets)
+	}
+	// a point counts once per distinct list element
+	facets, _ = c.Facet("tags", nil, 1)
+	if !reflect.DeepEqual(facets, []FacetValue{{"b", 2}}) {
+		t.Errorf("unexpected tag facets %v", facets)
+	}
+
+	stats, err := c.NumericStats("price", nil)
+	if err != nil {
+		t.Fatalf("NumericStats failed: %v", err)
+	}
+	if stats != (NumericStats{Count: 3, Min: 2.5, Max: 40}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	stats, _ = c.NumericStats("price", &Filter{MustNot: []Condition{{Key: "city", Match: "Delhi"}}})
+	if stats != (NumericStats{Count: 2, Min: 2.5, Max: 10}) {
+		t.Errorf("unexpected filtered stats %+v", stats)
+	}
+
+	c.Delete(ids[0])
+	if facets, _ := c.Facet("city", nil, 0); facets[0] != (FacetValue{"Delhi", 1}) {
+		t.Errorf("deleted points must not be counted, got %v", facets)
+	}
+}
@@ -0,0 +1,266 @@
+package collection
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// A backup is a tar archive holding config.json, the WAL segments cut at one LSN and a manifest
+// with the size and SHA-256 of every other file, written last. Backup pins a snapshot for the
+// LSN, so the archive replays to exactly the state the collection had at that moment while
+// writers continue. Payloads are not archived: the WAL carries them and the payload store is
+// rebuilt when the restored collection is opened.
+
+const (
+	backupFormatVersion = 1
+	backupManifestName  = "manifest.json"
+	backupConfigName    = "config.json"
+	// manifests list a handful of files, anything larger is not one
+	maxManifestSize = 1 << 20
+)
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	Version    int
+	Collection string
+	// last WAL record the backup includes
+	LSN   uint64
+	Files []BackupFile
+}
+
+// BackupFile is one archived file, Name is relative to the collection directory
+type BackupFile struct {
+	Name   string
+	Size   int64
+	SHA256 string
+}
+
+// Backup writes a self contained archive of the collection to dest while it stays online.
+// The archive is written next to dest and renamed into place once complete, an existing dest
+// is replaced
+func (c *Collection) Backup(dest string) (BackupManifest, error) {
+	s, err := c.Snapshot()
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	defer s.Release()
+	// the config never changes after create
+	cfg, err := encodeConfig(c.config)
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".tmp-*")
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	manifest := BackupManifest{Version: backupFormatVersion, Collection: c.config.Name, LSN: s.LSN()}
+	tw := tar.NewWriter(tmp)
+	add := func(name string, size int64, r io.Reader) error {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: size, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		sum := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(tw, sum), io.LimitReader(r, size)); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, BackupFile{Name: name, Size: size, SHA256: hex.EncodeToString(sum.Sum(nil))})
+		return nil
+	}
+	if err := add(backupConfigName, int64(len(cfg)), bytes.NewReader(cfg)); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to archive config: %w", err)
+	}
+	err = c.wal.CopySegments(s.LSN(), func(name string, size int64, r io.Reader) error {
+		return add(path.Join("wal", name), size, r)
+	})
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to archive wal: %w", err)
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	hdr := &tar.Header{Name: backupManifestName, Mode: 0644, Size: int64(len(manifestJSON)), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to archive manifest: %w", err)
+	}
+	if _, err := tw.Write(manifestJSON); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to archive manifest: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to finish backup: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to sync backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to close backup: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	return manifest, nil
+}
+
+// RestoreCollection unpacks a backup archive into rootDir as the collection it was taken of,
+// open it with OpenCollection afterwards. Every file is checked against the manifest before the
+// collection appears, a collection of the same name must not exist yet
+func RestoreCollection(archive, rootDir string) (BackupManifest, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer f.Close()
+	staging, err := os.MkdirTemp(rootDir, ".restore-*")
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to create restore directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	// files go to a placeholder directory until the manifest names the collection
+	unpacked := filepath.Join(staging, "collection")
+	if err := os.MkdirAll(filepath.Join(unpacked, "wal"), 0755); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to create restore directory: %w", err)
+	}
+
+	var manifest *BackupManifest
+	got := make(map[string]BackupFile)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return BackupManifest{}, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return BackupManifest{}, fmt.Errorf("%w: unexpected entry %q", ErrInvalidBackup, hdr.Name)
+		}
+		if hdr.Name == backupManifestName {
+			if manifest, err = readManifest(tr); err != nil {
+				return BackupManifest{}, err
+			}
+			continue
+		}
+		if !validBackupName(hdr.Name) {
+			return BackupManifest{}, fmt.Errorf("%w: unexpected entry %q", ErrInvalidBackup, hdr.Name)
+		}
+		if _, dup := got[hdr.Name]; dup {
+			return BackupManifest{}, fmt.Errorf("%w: duplicate entry %q", ErrInvalidBackup, hdr.Name)
+		}
+		file, err := unpackFile(tr, filepath.Join(unpacked, filepath.FromSlash(hdr.Name)))
+		if err != nil {
+			return BackupManifest{}, err
+		}
+		file.Name = hdr.Name
+		got[hdr.Name] = file
+	}
+	if manifest == nil {
+		return BackupManifest{}, fmt.Errorf("%w: missing manifest", ErrInvalidBackup)
+	}
+	if err := manifest.verify(got); err != nil {
+		return BackupManifest{}, err
+	}
+
+	name := manifest.Collection
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return BackupManifest{}, fmt.Errorf("%w: invalid collection name %q", ErrInvalidBackup, name)
+	}
+	target := filepath.Join(rootDir, name)
+	if _, err := os.Stat(target); err == nil {
+		return BackupManifest{}, ErrCollectionAlreadyExists
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return BackupManifest{}, fmt.Errorf("failed to stat collection directory: %w", err)
+	}
+	named := filepath.Join(staging, name)
+	if err := os.Rename(unpacked, named); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to restore collection: %w", err)
+	}
+	// validated like an open would
+	if _, err := loadConfig(staging, name); err != nil {
+		return BackupManifest{}, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if err := os.Rename(named, target); err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to restore collection: %w", err)
+	}
+	return *manifest, nil
+}
+
+// config.json and WAL segments are the only files a backup holds
+func validBackupName(name string) bool {
+	if name == backupConfigName {
+		return true
+	}
+	ok, _ := path.Match("wal/[0-9]*.waldrky", name)
+	return ok
+}
+
+func readManifest(r io.Reader) (*BackupManifest, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if len(data) > maxManifestSize {
+		return nil, fmt.Errorf("%w: manifest too large", ErrInvalidBackup)
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: corrupt manifest: %v", ErrInvalidBackup, err)
+	}
+	if manifest.Version != backupFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, manifest.Version)
+	}
+	return &manifest, nil
+}
+
+// writes one archived file to dst, returns its size and checksum
+func unpackFile(r io.Reader, dst string) (BackupFile, error) {
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return BackupFile{}, fmt.Errorf("failed to restore %s: %w", dst, err)
+	}
+	defer out.Close()
+	sum := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, sum), r)
+	if err != nil {
+		return BackupFile{}, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if err := out.Sync(); err != nil {
+		return BackupFile{}, fmt.Errorf("failed to restore %s: %w", dst, err)
+	}
+	return BackupFile{Size: size, SHA256: hex.EncodeToString(sum.Sum(nil))}, nil
+}
+
+// checks the unpacked files are exactly the ones the manifest lists
+func (m *BackupManifest) verify(got map[string]BackupFile) error {
+	if len(got) != len(m.Files) {
+		return fmt.Errorf("%w: manifest lists %d files, archive holds %d", ErrInvalidBackup, len(m.Files), len(got))
+	}
+	hasConfig := false
+	for _, want := range m.Files {
+		file, ok := got[want.Name]
+		if !ok {
+			return fmt.Errorf("%w: missing %s", ErrInvalidBackup, want.Name)
+		}
+		if file != want {
+			return fmt.Errorf("%w: checksum mismatch for %s", ErrInvalidBackup, want.Name)
+		}
+		hasConfig = hasConfig || want.Name == backupConfigName
+	}
+	if !hasConfig {
+		return fmt.Errorf("%w: missing %s", ErrInvalidBackup, backupConfigName)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// SearchBatch runs many searches of the default vector under one read lock, results[i] is what
+// Search(queries[i], k) returns. Every query is validated before any is searched, and indexes
+// that score blocks of queries together (index.BatchSearcher) get all of them at once
+func (c *Collection) SearchBatch(queries [][]float32, k int) ([][]Result, error) {
+	return c.SearchBatchContext(context.Background(), queries, k)
+}
+
+// SearchBatchContext is SearchBatch bounded by ctx, the whole batch fails with ctx.Err() once
+// it is done
+func (c *Collection) SearchBatchContext(ctx context.Context, queries [][]float32, k int) ([][]Result, error) {
+	if k <= 0 {
+		return nil, errors.New("invalid input for number of results")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	field, err := c.denseField(DefaultVectorField)
+	if err != nil {
+		return nil, err
+	}
+	vecs := make([]*vector.Vector, len(queries))
+	for i, vals := range queries {
+		if vecs[i], err = field.newQuery(vals); err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
+		}
+	}
+	// expired points not reaped yet are fetched and dropped
+	expired := c.expiredIDs()
+	fetch := k + len(expired)
+	var idxResults [][]index.SearchResult
+	if batcher, ok := field.idx.(index.BatchSearcher); ok {
+		if idxResults, err = batcher.SearchBatch(ctx, vecs, fetch); err != nil {
+			return nil, err
+		}
+	} else {
+		idxResults = make([][]index.SearchResult, len(vecs))
+		for i, vec := range vecs {
+			if idxResults[i], err = field.idx.SearchContext(ctx, vec, fetch); err != nil {
+				return nil, fmt.Errorf("query %d: %w", i, err)
+			}
+		}
+	}
+	results := make([][]Result, len(idxResults))
+	for i, idxResult := range idxResults {
+		if results[i], err = c.toResults(dropExpired(idxResult, expired, k)); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
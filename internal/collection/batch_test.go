@@ -0,0 +1,32 @@
+package collection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCollection_SearchBatch(t *testing.T) {
+	c, _, _, _, _, _ := recommendCollection(t)
+	queries := [][]float32{{1, 0}, {0, 1}, {-1, -1}}
+	results, err := c.SearchBatch(queries, 3)
+	if err != nil {
+		t.Fatalf("SearchBatch failed: %v", err)
+	}
+	if len(results) != len(queries) {
+		t.Fatalf("expected %d result lists, got %d", len(queries), len(results))
+	}
+	for i, q := range queries {
+		want, _ := c.Search(q, 3)
+		if !reflect.DeepEqual(results[i], want) {
+			t.Errorf("query %d: batch %v, single %v", i, results[i], want)
+		}
+	}
+
+	if _, err := c.SearchBatch([][]float32{{1, 0}, {1}}, 3); !errors.Is(err, ErrInvalidDimension) {
+		t.Errorf("expected ErrInvalidDimension, got %v", err)
+	}
+	if _, err := c.SearchBatch(queries, 0); err == nil {
+		t.Error("expected an error for k=0")
+	}
+}
@@ -1,14 +1,18 @@
 package collection
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/store/payload"
 	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
 	"github.com/Kasbe14/Dattaniddhi/internal/types"
 	"github.com/Kasbe14/Dattaniddhi/internal/vector"
@@ -20,16 +24,34 @@ var ErrCollectionNotFound = errors.New("collection not found")
 
 // collection owns index and its lifecyle ensures collection level invariants
 type Collection struct {
-	mu        sync.RWMutex
-	config    CollectionConfig
-	index     index.VectorIndex
+	mu     sync.RWMutex
+	config CollectionConfig
+	index  index.VectorIndex
+	// set instead of index for sparse (InvertedIndex) collections, next to it for hybrid ones
+	sparse index.SparseIndex
+	// named vector field -> its dense index, nil without named fields
+	fields map[string]index.VectorIndex
+	// multi vector field -> its MaxSim index, nil without multi vector fields
+	multi     map[string]index.MultiVectorIndex
 	idCounter int
 	//id mappings exteranl user usage internal internal processing
 	extToInt map[string]int
 	intToExt map[int]string
-	//  Playload in-memory
-	payload map[string]any
-	wal     *wal.WAL
+	// payloads by external id, in memory or on disk depending on the config
+	payloads payload.PayloadStore
+	wal      *wal.WAL
+	// internal id -> expiry time in unix nanoseconds, only points that expire
+	expiry map[int]int64
+	// clock of the expiry checks, nil is time.Now
+	now func() time.Time
+	// deletes expired points in the background, nil until the collection has a DefaultTTL or
+	// an expiring point
+	reaper *reaper
+	// set by Close, no reaper is started afterwards
+	closing bool
+	// open snapshots and the prior state of the points changed since the oldest one
+	snapshots map[*Snapshot]struct{}
+	undo      undoLog
 }
 
 type Result struct {
@@ -46,18 +68,46 @@ func CreateCollection(cfg CollectionConfig, path string, sync wal.SyncPolicy) (*
 	if cfg.Name == "" {
 		return nil, ErrInvalidCollectionName
 	}
-	switch cfg.Metric {
-	case types.Cosine, types.Dot, types.Euclidean:
-	//ok valid input
-	default:
+	if !vector.IsRegisteredMetric(cfg.Metric) {
 		return nil, ErrInvalidMetric
 	}
 	switch cfg.IndexType {
-	case types.HNSWIndex, types.LinearIndex, types.IVFIndex, types.PQIndex:
+	case types.HNSWIndex, types.LinearIndex, types.IVFIndex, types.PQIndex, types.InvertedIndex:
 	//ok valid input
 	default:
 		return nil, ErrInvalidIndexType
 	}
+	if err := validateQuantization(cfg); err != nil {
+		return nil, err
+	}
+	switch cfg.Precision {
+	case types.Float32Precision, types.Float16Precision, types.BFloat16Precision:
+	//ok valid input
+	default:
+		return nil, ErrInvalidPrecision
+	}
+	// quantized indexes own their compressed layout, half precision storage cannot apply to them
+	if cfg.Precision != types.Float32Precision && cfg.Quantization.Type != types.NoQuantization {
+		return nil, fmt.Errorf("%w: quantization requires float32 precision", ErrInvalidPrecision)
+	}
+	switch cfg.PayloadStorage {
+	case types.InMemoryPayloads, types.OnDiskPayloads:
+	//ok valid input
+	default:
+		return nil, ErrInvalidPayloadStorage
+	}
+	if err := validateSparseConfig(cfg); err != nil {
+		return nil, err
+	}
+	if err := validatePayloadSchema(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateVectorFields(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateTTL(cfg); err != nil {
+		return nil, err
+	}
 	cfgPath := filepath.Join(path, cfg.Name, "config.json")
 	_, err := os.Stat(cfgPath)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
@@ -66,39 +116,46 @@ func CreateCollection(cfg CollectionConfig, path string, sync wal.SyncPolicy) (*
 	if err == nil {
 		return nil, ErrCollectionAlreadyExists
 	}
+	// indexes are built before the config reaches disk, a config they reject must not leave
+	// a collection behind that can neither be created again nor opened
+	idx, sparseIdx, err := newIndexes(cfg)
+	if err != nil {
+		return nil, err
+	}
+	fields, multi, err := newFieldIndexes(cfg)
+	if err != nil {
+		return nil, err
+	}
 	//assigning the version
 	cfg.Version = collectionConfigVersion
 	err = saveConfig(cfg, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create collection %w", err)
 	}
-	indexConfig, err := index.NewIndexConfig(cfg.IndexType, cfg.Metric, cfg.Dimension)
-	if err != nil {
-		return nil, fmt.Errorf("index config creation failed: %w", err)
-	}
-	//pointer value satisfying IndexFactory interface
-	var indexFactory index.DefaultIndexFactory
-	//return new index instance of type cfg.IndexType
-	idx, err := indexFactory.CreateIndex(indexConfig)
+	payloads, err := newPayloadStore(cfg, path, sync)
 	if err != nil {
-		return nil, fmt.Errorf("index creation failed: %w", err)
+		return nil, fmt.Errorf("failed to create collection: %w", err)
 	}
 	//create wal instance for the collection
 	walPath := filepath.Join(path, cfg.Name, "wal")
 	wal, err := wal.NewWAL(walPath, sync)
 	if err != nil {
-		//wal.Close()
+		payloads.Close()
 		return nil, fmt.Errorf("failed to create collection: %w", err)
 	}
 	collection := &Collection{
 		config:    cfg,
 		index:     idx,
+		sparse:    sparseIdx,
+		fields:    fields,
+		multi:     multi,
 		idCounter: 0,
 		extToInt:  make(map[string]int),
 		intToExt:  make(map[int]string),
-		payload:   make(map[string]any),
+		payloads:  payloads,
 		wal:       wal,
 	}
+	collection.ensureReaper()
 	return collection, nil
 }
 
@@ -111,32 +168,36 @@ func OpenCollection(path, collectionName string, sync wal.SyncPolicy) (*Collecti
 		return nil, fmt.Errorf("failed to open collection [%s]: %w", collectionName, err)
 	}
 
-	indexConfig, err := index.NewIndexConfig(collectionConfig.IndexType, collectionConfig.Metric, collectionConfig.Dimension)
+	idx, sparseIdx, err := newIndexes(*collectionConfig)
 	if err != nil {
-		return nil, fmt.Errorf("index config creation failed: %w", err)
+		return nil, err
 	}
-	//pointer value satisfying IndexFactory interface
-	//indexFactory := &index.DefaultIndexFactory{}
-	var indexFactory index.DefaultIndexFactory
-	//return new index instance of type cfg.IndexType
-	idx, err := indexFactory.CreateIndex(indexConfig)
+	fields, multi, err := newFieldIndexes(*collectionConfig)
 	if err != nil {
-		return nil, fmt.Errorf("index creation failed: %w", err)
+		return nil, err
 	}
 
+	payloads, err := newPayloadStore(*collectionConfig, path, sync)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open collection [%s]: %w", collectionName, err)
+	}
 	walPath := filepath.Join(path, collectionName, "wal")
 	wal, err := wal.NewWAL(walPath, sync)
 	if err != nil {
+		payloads.Close()
 		return nil, fmt.Errorf("failed to open collection [%s]: %w", collectionName, err)
 	}
 
 	collection := &Collection{
 		config:    *collectionConfig,
 		index:     idx,
+		sparse:    sparseIdx,
+		fields:    fields,
+		multi:     multi,
 		idCounter: 0,
 		extToInt:  make(map[string]int),
 		intToExt:  make(map[int]string),
-		payload:   make(map[string]any),
+		payloads:  payloads,
 		wal:       wal,
 	}
 	//Loading the collection if exist else return new empty instance
@@ -144,42 +205,158 @@ func OpenCollection(path, collectionName string, sync wal.SyncPolicy) (*Collecti
 	if err != nil {
 		//closing the wal so no leak/ghost wal remains
 		wal.Close()
+		payloads.Close()
 		return nil, fmt.Errorf("failed to open the collection %s: %w", collection.config.Name, err)
 	}
 	return collection, nil
 }
 
 func (c *Collection) Insert(vecVals []float32, payload any) (string, error) {
+	return c.InsertContext(context.Background(), vecVals, payload)
+}
+
+// InsertContext is Insert that gives up with ctx.Err() if ctx is done before the point is
+// logged. Waiting for the write lock can not be interrupted, ctx is checked again once it is
+// held. A logged insert always completes
+func (c *Collection) InsertContext(ctx context.Context, vecVals []float32, payload any) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.insertPoint(pointInput{dense: vecVals}, payload)
+}
+
+// every vector of one point as handed to the insert APIs
+type pointInput struct {
+	dense []float32
+	// sparse part of hybrid points
+	sparseIndices []uint32
+	sparseValues  []float32
+	// named field -> vector, fields may be left out
+	named map[string][]float32
+	// multi vector field -> token vectors, fields may be left out
+	multi map[string][][]float32
+	// zero falls back to the collection's DefaultTTL
+	expiresAt time.Time
+}
+
+// one validated vector or multi vector waiting to be added to its index
+type pendingAdd struct {
+	idx index.VectorIndex
+	vec *vector.Vector
+
+	multiIdx index.MultiVectorIndex
+	multiVec *vector.MultiVector
+}
+
+func (p pendingAdd) add(internalID int) (bool, error) {
+	if p.multiIdx != nil {
+		return p.multiIdx.Add(internalID, p.multiVec)
+	}
+	return p.idx.Add(internalID, p.vec)
+}
 
+func (p pendingAdd) delete(internalID int) error {
+	if p.multiIdx != nil {
+		return p.multiIdx.Delete(internalID)
+	}
+	return p.idx.Delete(internalID)
+}
+
+// inserts a dense point with its optional sparse part, named field vectors and multi vectors, all of them
+// are logged in one WAL record so a crash never leaves half a point. Caller holds c.mu
+func (c *Collection) insertPoint(in pointInput, payload any) (string, error) {
 	// 1. Validation phase (Fails fast, no state changed)
-	if len(vecVals) != c.config.Dimension {
-		return "", ErrInvalidDimension
+	field, err := c.denseField(DefaultVectorField)
+	if err != nil {
+		return "", err
 	}
-	vector, err := vector.NewVector(vecVals, c.config.Dimension)
+	vec, err := field.newVector(in.dense)
 	if err != nil {
 		return "", err
 	}
-	metaData, err := json.Marshal(payload)
+	// half precision cannot hold arbitrary magnitudes (float16 tops out at 65504),
+	// so those collections log the constructed values which are known to fit
+	walRec := wal.InsertRecord{
+		Vector:    field.walValues(in.dense, vec),
+		Precision: field.precision,
+	}
+	adds := []pendingAdd{{idx: c.index, vec: vec}}
+	// sorted so the same point always encodes to the same record
+	names := slices.Sorted(maps.Keys(in.named))
+	for _, name := range names {
+		if name == DefaultVectorField {
+			return "", fmt.Errorf("%w: the default vector is not a named field", ErrInvalidVectorField)
+		}
+		field, err := c.denseField(name)
+		if err != nil {
+			return "", err
+		}
+		namedVec, err := field.newVector(in.named[name])
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", name, err)
+		}
+		walRec.NamedVectors = append(walRec.NamedVectors, wal.NamedVector{
+			Name:      name,
+			Vector:    field.walValues(in.named[name], namedVec),
+			Precision: field.precision,
+		})
+		adds = append(adds, pendingAdd{idx: field.idx, vec: namedVec})
+	}
+	for _, name := range slices.Sorted(maps.Keys(in.multi)) {
+		field, err := c.multiField(name)
+		if err != nil {
+			return "", err
+		}
+		multiVec, err := field.newMultiVector(in.multi[name])
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", name, err)
+		}
+		walRec.MultiVectors = append(walRec.MultiVectors, wal.NamedMultiVector{
+			Name:      name,
+			Vectors:   field.walRows(in.multi[name], multiVec),
+			Precision: field.precision,
+		})
+		adds = append(adds, pendingAdd{multiIdx: field.idx, multiVec: multiVec})
+	}
+	var sparseVec *vector.SparseVector
+	if len(in.sparseIndices) > 0 || len(in.sparseValues) > 0 {
+		if c.sparse == nil {
+			return "", ErrVectorKindMismatch
+		}
+		sparseVec, err = c.newSparseVector(in.sparseIndices, in.sparseValues)
+		if err != nil {
+			return "", err
+		}
+		walRec.SparseIndices, walRec.SparseValues = sparseVec.Indices(), sparseVec.Values()
+	}
+	payload, walRec.MetaData, err = c.encodePayload(payload)
 	if err != nil {
 		return "", err
 	}
+	if walRec.ExpiresAt, err = c.expiryFor(in.expiresAt); err != nil {
+		return "", err
+	}
 
 	// 2. Prepare data (Still no state permanently changed)
 	externalID := uuid.NewString()
 	// Calculate the NEXT internal ID, but don't commit it to c.idCounter yet!
 	internalID := c.idCounter + 1
+	walRec.ExtID, walRec.IntID = externalID, uint64(internalID)
 
 	// 3. The Point of No Return: Write to WAL
-	_, err = c.wal.AppendInsert(externalID, uint64(internalID), vecVals, metaData)
+	_, err = c.wal.AppendInsertPoint(walRec)
 	if err != nil {
 		// If disk fails, we just return. No memory was mutated, so nothing to clean up!
 		return "", err
 	}
 
 	// 4. Memory Mutation (The WAL succeeded, now we update everything)
-	added, err := c.index.Add(internalID, vector)
+	added, err := c.addToIndexes(internalID, adds, sparseVec)
 	if err != nil || !added {
 		// CRITICAL EDGE CASE: If the WAL succeeded but the memory index fails,
 		// the DB is now in an inconsistent state.
@@ -197,53 +374,120 @@ func (c *Collection) Insert(vecVals []float32, payload any) (string, error) {
 		return "", ErrInternalIDCollision
 	}
 
-	// 5. Finalize State
+	// 5. Store the payload, a failure cancels the point like a refused index add
+	if err := c.storePayload(externalID, internalID, payload, walRec.MetaData); err != nil {
+		return "", err
+	}
+
+	// 6. Finalize State
 	// The index succeeded, so we finally commit the ID counter and maps
 	c.idCounter = internalID
 	c.extToInt[externalID] = internalID
 	c.intToExt[internalID] = externalID
-	c.payload[externalID] = payload
+	c.setExpiry(internalID, walRec.ExpiresAt)
+	c.ensureReaper()
 
 	return externalID, nil
 }
-func (c *Collection) Search(queryVals []float32, k int) ([]Result, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.config.Dimension != len(queryVals) {
-		return []Result{}, ErrInvalidDimension
+
+// adds all vectors of one point, if any index refuses the ones already added are taken out
+// again so the indexes never disagree about which points exist
+func (c *Collection) addToIndexes(internalID int, adds []pendingAdd, sparseVec *vector.SparseVector) (bool, error) {
+	for i, add := range adds {
+		added, err := add.add(internalID)
+		if err != nil || !added {
+			for _, done := range adds[:i] {
+				done.delete(internalID)
+			}
+			return added, err
+		}
 	}
-	queryVector, err := vector.NewVector(queryVals, c.config.Dimension)
-	if err != nil {
-		return []Result{}, err
+	if sparseVec == nil {
+		return true, nil
 	}
-	idxResult, err := c.index.Search(queryVector, k)
-	if err != nil {
-		return []Result{}, err
+	added, err := c.sparse.Add(internalID, sparseVec)
+	if err != nil || !added {
+		for _, done := range adds {
+			done.delete(internalID)
+		}
 	}
-	colResult := make([]Result, len(idxResult))
+	return added, err
+}
 
-	for i, val := range idxResult {
-		if _, ok := c.intToExt[val.VecId]; !ok {
-			return []Result{}, errors.New("id doesn't exist internal corruption")
-		}
-		colResult[i] = Result{c.intToExt[val.VecId], val.Score}
+// stores the payload of a point whose vectors are already indexed, on failure the vectors are
+// taken out again and the insert is cancelled in the WAL, caller holds c.mu
+func (c *Collection) storePayload(externalID string, internalID int, payload any, raw []byte) error {
+	err := c.payloads.Put(externalID, payload, raw)
+	if err == nil {
+		return nil
+	}
+	if idxErr := c.deleteFromIndex(internalID); idxErr != nil {
+		return fmt.Errorf("payload store failed: %v, index rollback failed: %v", err, idxErr)
+	}
+	if _, walErr := c.wal.AppendDelete(externalID, uint64(internalID)); walErr != nil {
+		return fmt.Errorf("payload store failed: %v, critical wal rollback failed: %v", err, walErr)
+	}
+	return fmt.Errorf("payload store failed: %w", err)
+}
+
+func (c *Collection) Search(queryVals []float32, k int) ([]Result, error) {
+	return c.SearchContext(context.Background(), queryVals, k)
+}
+
+// SearchContext is Search bounded by ctx, the index scan stops with ctx.Err() once it is done
+func (c *Collection) SearchContext(ctx context.Context, queryVals []float32, k int) ([]Result, error) {
+	if err := ctx.Err(); err != nil {
+		return []Result{}, err
 	}
-	return colResult, nil
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.searchField(ctx, DefaultVectorField, queryVals, k)
 }
 func (c *Collection) Delete(id string) error {
+	return c.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext is Delete that gives up with ctx.Err() if ctx is done before the delete is
+// logged. Waiting for the write lock can not be interrupted, ctx is checked again once it is
+// held
+func (c *Collection) DeleteContext(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	internalID, ok := c.extToInt[id]
-	if !ok {
+	if !ok || c.expired(internalID) {
 		return ErrNotFound
 	}
-	//payload := c.payload // used for soft deletes tombstones(easeir rollback)
+	return c.deletePoint(id, internalID)
+}
+
+// logs the delete of a point and removes it everywhere, caller holds c.mu
+func (c *Collection) deletePoint(id string, internalID int) error {
+	// open snapshots still see the point, its state is saved before anything is removed
+	var payload any
+	var vectors *Record
+	if len(c.snapshots) > 0 {
+		var err error
+		if payload, _, err = c.payloads.Get(id); err != nil {
+			return fmt.Errorf("failed to read payload of %s: %w", id, err)
+		}
+		vectors = &Record{ID: id}
+		if err := c.fillVectors(vectors, internalID); err != nil {
+			return err
+		}
+	}
 	// write/append operation to the Wal segment
-	_, err := c.wal.AppendDelete(id, uint64(internalID))
+	lsn, err := c.wal.AppendDelete(id, uint64(internalID))
 	if err != nil {
 		return err
 	}
-	err = c.index.Delete(internalID)
+	c.saveUndo(internalID, lsn, payload, vectors)
+	err = c.deleteFromIndex(internalID)
 	if err != nil {
 		// The disk and memory are now permanently out of sync.
 		// Solution: Crash the database immediately to prevent data corruption.
@@ -251,13 +495,26 @@ func (c *Collection) Delete(id string) error {
 	}
 	delete(c.extToInt, id)
 	delete(c.intToExt, internalID)
-	delete(c.payload, id)
+	delete(c.expiry, internalID)
+	// the point is gone either way, a payload left behind is dropped on the next open
+	if err := c.payloads.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete payload of %s: %w", id, err)
+	}
 	return nil
 }
+
+// Get returns the payload of a point, on disk payloads are read and decoded on every call
+// and are reported missing if the store can not read them
 func (c *Collection) Get(id string) (any, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	payload, ok := c.payload[id]
+	if internalID, ok := c.extToInt[id]; !ok || c.expired(internalID) {
+		return nil, false
+	}
+	payload, ok, err := c.payloads.Get(id)
+	if err != nil {
+		return nil, false
+	}
 	return payload, ok
 }
 
@@ -267,10 +524,24 @@ func (c *Collection) GetIdCounter() int {
 
 // Close safely shuts down the underlying storage engine and flushes to disk.
 func (c *Collection) Close() error {
+	// a reap must not run into the closed WAL
+	c.mu.Lock()
+	c.closing = true
+	r := c.reaper
+	c.mu.Unlock()
+	if r != nil {
+		r.shutdown()
+	}
+	var err error
 	if c.wal != nil {
-		return c.wal.Close()
+		err = c.wal.Close()
 	}
-	return nil
+	if c.payloads != nil {
+		if storeErr := c.payloads.Close(); err == nil {
+			err = storeErr
+		}
+	}
+	return err
 }
 
 //helper functions
@@ -278,12 +549,19 @@ func (c *Collection) Close() error {
 func (c *Collection) IDCounter() int {
 	return c.idCounter
 }
+
+// ExtToInt returns a copy of the id mapping, use Scroll to enumerate points
 func (c *Collection) ExtToInt() map[string]int {
-	return c.extToInt
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return maps.Clone(c.extToInt)
 }
 
+// IntToExt returns a copy of the id mapping
 func (c *Collection) IntToExt() map[int]string {
-	return c.intToExt
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return maps.Clone(c.intToExt)
 }
 func (c *Collection) Name() string {
 	return c.config.Name
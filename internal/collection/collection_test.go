@@ -152,7 +152,7 @@ func TestCollection_Delete(t *testing.T) {
 	}
 
 	// Assert
-	if len(col.extToInt) != 0 || len(col.payload) != 0 {
+	if len(col.extToInt) != 0 || col.payloads.Len() != 0 {
 		t.Error("Memory maps not cleared after delete")
 	}
 }
@@ -169,7 +169,7 @@ func TestCollection_Delete_SplitBrainSelfHealing(t *testing.T) {
 	c.mu.Lock()
 	c.extToInt["ghost-doc"] = 99
 	c.intToExt[99] = "ghost-doc"
-	c.payload["ghost-doc"] = []byte(`{}`)
+	c.payloads.Put("ghost-doc", []byte(`{}`), []byte(`{}`))
 	c.mu.Unlock()
 
 	// 2. Act
@@ -281,8 +281,8 @@ func TestCollection_Concurrency(t *testing.T) {
 	}
 	wg.Wait()
 
-	if len(col.payload) != count {
-		t.Errorf("Expected %d items, got %d", count, len(col.payload))
+	if col.payloads.Len() != count {
+		t.Errorf("Expected %d items, got %d", count, col.payloads.Len())
 	}
 }
 
@@ -384,3 +384,33 @@ func TestCollection_HighVolumeRecovery(t *testing.T) {
 		t.Errorf("Expected idCounter to be %d, got %d", numVectors, c2.idCounter)
 	}
 }
+
+func TestCollection_Search_HammingMetric(t *testing.T) {
+	c, err := CreateCollection(CollectionConfig{
+		Name:      "hamming",
+		Dimension: 4,
+		Metric:    types.Hamming,
+		IndexType: types.LinearIndex,
+		DataType:  types.Text,
+		ModelName: "model",
+	}, t.TempDir(), wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	if _, err := c.Insert([]float32{1, 0.5, 0, 0}, nil); err == nil {
+		t.Fatal("expected error inserting non binary vector into hamming collection")
+	}
+	near, _ := c.Insert([]float32{1, 1, 0, 0}, nil)
+	far, _ := c.Insert([]float32{0, 0, 1, 1}, nil)
+	results, err := c.Search([]float32{1, 1, 1, 0}, 2)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if results[0].VecID != near || results[1].VecID != far {
+		t.Errorf("unexpected order %+v", results)
+	}
+	if results[0].Score != -1 || results[1].Score != -3 {
+		t.Errorf("expected hamming scores -1 and -3, got %v and %v", results[0].Score, results[1].Score)
+	}
+}
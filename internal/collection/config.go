@@ -6,12 +6,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/store/payload"
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
 	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
 )
 
 const collectionConfigVersion uint32 = 1
 
+// file of the on disk payload store inside the collection directory
+const payloadFileName = "payloads.log"
+
 // configuration for the collection
 // existence of config.json
 // becomes authoritative collection existence signal
@@ -23,6 +31,137 @@ type CollectionConfig struct {
 	DataType  types.DataType
 	ModelName string
 	Version   uint32
+	// in-memory storage mode of the index, zero value is full precision float32
+	// the WAL always keeps the original float32 vectors so replay re-quantizes from them
+	Quantization index.QuantizationConfig
+	// width of stored vector components in the WAL and the index, zero value is float32
+	Precision types.Precision
+	// optional sparse representation stored next to the dense vector of every point,
+	// nil for plain dense collections and for sparse (InvertedIndex) collections
+	Sparse *SparseConfig
+	// optional named dense fields stored next to the default vector, e.g. body and image
+	// embeddings of a document whose title embedding is the default vector
+	Vectors []VectorField
+	// where point payloads are kept, zero value is the in-memory map
+	PayloadStorage types.PayloadStorage
+	// optional typed payload fields validated on insert, nil accepts any payload
+	PayloadSchema *PayloadSchema
+	// lifetime of points inserted without an explicit expiry, zero value never expires
+	DefaultTTL time.Duration
+	// how often expired points are deleted, zero value is once a minute
+	ReapInterval time.Duration
+}
+
+// VectorField declares one named dense embedding of a point with its own index
+type VectorField struct {
+	Name         string
+	Dimension    int
+	Metric       types.SimilarityMetric
+	IndexType    types.IndexType
+	Quantization index.QuantizationConfig
+	Precision    types.Precision
+	// points hold a bag of token vectors of Dimension for this field instead of one vector,
+	// searched with SearchMaxSim. The index settings apply to the token index
+	MultiVector bool
+}
+
+// builds the index config of a named field
+func (vf VectorField) indexConfig() (index.IndexConfig, error) {
+	indexConfig, err := index.NewIndexConfig(vf.IndexType, vf.Metric, vf.Dimension)
+	if err != nil {
+		return index.IndexConfig{}, err
+	}
+	indexConfig, err = indexConfig.WithQuantization(vf.Quantization)
+	if err != nil {
+		return index.IndexConfig{}, err
+	}
+	return indexConfig.WithPrecision(vf.Precision)
+}
+
+// named fields need unique non empty names and a valid dense index config each
+func validateVectorFields(cfg CollectionConfig) error {
+	if len(cfg.Vectors) > 0 && cfg.IndexType == types.InvertedIndex {
+		return fmt.Errorf("%w: sparse collections cannot declare named vector fields", ErrInvalidVectorField)
+	}
+	seen := make(map[string]bool, len(cfg.Vectors))
+	for _, vf := range cfg.Vectors {
+		if vf.Name == "" {
+			return fmt.Errorf("%w: empty field name, the default vector has no name", ErrInvalidVectorField)
+		}
+		if seen[vf.Name] {
+			return fmt.Errorf("%w: duplicate field %s", ErrInvalidVectorField, vf.Name)
+		}
+		seen[vf.Name] = true
+		if vf.IndexType == types.InvertedIndex {
+			return fmt.Errorf("%w: field %s: named fields must be dense", ErrInvalidVectorField, vf.Name)
+		}
+		if _, err := vf.indexConfig(); err != nil {
+			return fmt.Errorf("%w: field %s: %v", ErrInvalidVectorField, vf.Name, err)
+		}
+	}
+	return nil
+}
+
+// quantization must suit the metric of the dense index, binary sign bits only preserve angles
+func validateQuantization(cfg CollectionConfig) error {
+	indexConfig, err := index.NewIndexConfig(cfg.IndexType, cfg.Metric, cfg.Dimension)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuantization, err)
+	}
+	if _, err := indexConfig.WithQuantization(cfg.Quantization); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuantization, err)
+	}
+	return nil
+}
+
+// SparseConfig turns a dense collection into a hybrid one, points may also carry
+// sparse term weights and SearchHybrid fuses dense and sparse rankings
+type SparseConfig struct {
+	// vocabulary size, sparse indices must be below it
+	Vocabulary int
+	// dot product family metric of the sparse side, zero value is InnerProduct
+	Metric types.SimilarityMetric
+}
+
+// metric the sparse index scores with
+func (sc SparseConfig) metric() types.SimilarityMetric {
+	if sc.Metric == 0 {
+		return types.InnerProduct
+	}
+	return sc.Metric
+}
+
+// sparse side of a collection: the index settings of a sparse (InvertedIndex) collection, or
+// cfg.Sparse of a hybrid one
+func validateSparseConfig(cfg CollectionConfig) error {
+	if cfg.IndexType == types.InvertedIndex {
+		if cfg.Sparse != nil {
+			return fmt.Errorf("%w: sparse collections cannot declare a second sparse representation", ErrInvalidSparseConfig)
+		}
+		if !isDotMetric(cfg.Metric) {
+			return fmt.Errorf("%w: only dot product metrics are supported", ErrInvalidSparseConfig)
+		}
+		return nil
+	}
+	if cfg.Sparse == nil {
+		return nil
+	}
+	if cfg.Sparse.Vocabulary <= 0 {
+		return fmt.Errorf("%w: vocabulary must be positive", ErrInvalidSparseConfig)
+	}
+	if !isDotMetric(cfg.Sparse.metric()) {
+		return fmt.Errorf("%w: only dot product metrics are supported", ErrInvalidSparseConfig)
+	}
+	return nil
+}
+
+// metrics the inverted index can score with
+func isDotMetric(metric types.SimilarityMetric) bool {
+	switch metric {
+	case types.Cosine, types.Dot, types.InnerProduct:
+		return true
+	}
+	return false
 }
 
 // constructor
@@ -40,14 +179,11 @@ func NewCollectionConfig(
 	if dim <= 0 {
 		return CollectionConfig{}, ErrInvalidDimension
 	}
-	switch metric {
-	case types.Cosine, types.Dot, types.Euclidean:
-	//ok valid input
-	default:
+	if !vector.IsRegisteredMetric(metric) {
 		return CollectionConfig{}, ErrInvalidMetric
 	}
 	switch idxType {
-	case types.LinearIndex, types.HNSWIndex, types.IVFIndex, types.PQIndex:
+	case types.LinearIndex, types.HNSWIndex, types.IVFIndex, types.PQIndex, types.InvertedIndex:
 	//ok valid input
 	default:
 		return CollectionConfig{}, ErrInvalidIndexType
@@ -73,10 +209,19 @@ func NewCollectionConfig(
 	}, nil
 }
 
-func saveConfig(cfg CollectionConfig, path string) error {
+// config.json contents
+func encodeConfig(cfg CollectionConfig) ([]byte, error) {
 	jsonData, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return jsonData, nil
+}
+
+func saveConfig(cfg CollectionConfig, path string) error {
+	jsonData, err := encodeConfig(cfg)
+	if err != nil {
+		return err
 	}
 	fullpath := filepath.Join(path /*,dbName*/, cfg.Name)
 	err = os.MkdirAll(fullpath, 0755)
@@ -116,14 +261,11 @@ func loadConfig(path, colName string) (*CollectionConfig, error) {
 	if config.Dimension <= 0 {
 		return nil, fmt.Errorf("invalid collection dimension")
 	}
-	switch config.Metric {
-	case types.Cosine, types.Dot, types.Euclidean:
-		//do nothing correct type
-	default:
+	if !vector.IsRegisteredMetric(config.Metric) {
 		return nil, fmt.Errorf("invalid collection metric type")
 	}
 	switch config.IndexType {
-	case types.HNSWIndex, types.IVFIndex, types.PQIndex, types.LinearIndex:
+	case types.HNSWIndex, types.IVFIndex, types.PQIndex, types.LinearIndex, types.InvertedIndex:
 		//do nthign valid data
 	default:
 		return nil, fmt.Errorf("invalid collection index type")
@@ -134,8 +276,130 @@ func loadConfig(path, colName string) (*CollectionConfig, error) {
 	default:
 		return nil, fmt.Errorf("invalid collection data type")
 	}
+	if err := config.Quantization.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid collection quantization: %w", err)
+	}
+	switch config.Precision {
+	case types.Float32Precision, types.Float16Precision, types.BFloat16Precision:
+		//do nothing valid data
+	default:
+		return nil, fmt.Errorf("invalid collection precision")
+	}
+	switch config.PayloadStorage {
+	case types.InMemoryPayloads, types.OnDiskPayloads:
+		//do nothing valid data
+	default:
+		return nil, fmt.Errorf("invalid collection payload storage")
+	}
+	if err := validateSparseConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid collection sparse config: %w", err)
+	}
+	if err := validatePayloadSchema(config); err != nil {
+		return nil, fmt.Errorf("invalid collection payload schema: %w", err)
+	}
+	if err := validateVectorFields(config); err != nil {
+		return nil, fmt.Errorf("invalid collection vector fields: %w", err)
+	}
+	if err := validateTTL(config); err != nil {
+		return nil, fmt.Errorf("invalid collection ttl: %w", err)
+	}
 	if collectionConfigVersion != config.Version {
 		return nil, fmt.Errorf("invalid collection config verison")
 	}
 	return &config, nil
 }
+
+// builds the index config described by the collection config
+func newIndexConfig(cfg CollectionConfig) (index.IndexConfig, error) {
+	indexConfig, err := index.NewIndexConfig(cfg.IndexType, cfg.Metric, cfg.Dimension)
+	if err != nil {
+		return index.IndexConfig{}, err
+	}
+	indexConfig, err = indexConfig.WithQuantization(cfg.Quantization)
+	if err != nil {
+		return index.IndexConfig{}, err
+	}
+	return indexConfig.WithPrecision(cfg.Precision)
+}
+
+// builds the indexes described by the collection config: a dense VectorIndex, a SparseIndex
+// for sparse collections, or both for hybrid ones, absent indexes are nil
+func newIndexes(cfg CollectionConfig) (index.VectorIndex, index.SparseIndex, error) {
+	indexConfig, err := newIndexConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("index config creation failed: %w", err)
+	}
+	//value satisfying IndexFactory interface
+	var indexFactory index.DefaultIndexFactory
+	if cfg.IndexType == types.InvertedIndex {
+		sparseIdx, err := indexFactory.CreateSparseIndex(indexConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("index creation failed: %w", err)
+		}
+		return nil, sparseIdx, nil
+	}
+	//return new index instance of type cfg.IndexType
+	idx, err := indexFactory.CreateIndex(indexConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("index creation failed: %w", err)
+	}
+	if cfg.Sparse == nil {
+		return idx, nil, nil
+	}
+	sparseConfig, err := index.NewIndexConfig(types.InvertedIndex, cfg.Sparse.metric(), cfg.Sparse.Vocabulary)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sparse index config creation failed: %w", err)
+	}
+	sparseIdx, err := indexFactory.CreateSparseIndex(sparseConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sparse index creation failed: %w", err)
+	}
+	return idx, sparseIdx, nil
+}
+
+// opens the payload store the config asks for, on disk stores live in the collection directory
+// and sync like the collection's WAL
+func newPayloadStore(cfg CollectionConfig, path string, sync wal.SyncPolicy) (payload.PayloadStore, error) {
+	if cfg.PayloadStorage == types.OnDiskPayloads {
+		var decode payload.DecodeFunc
+		if cfg.PayloadSchema != nil {
+			decode = func(raw []byte) (any, error) { return cfg.PayloadSchema.decode(raw) }
+		}
+		return payload.OpenFileStore(filepath.Join(path, cfg.Name, payloadFileName), decode, sync)
+	}
+	return payload.NewMemoryStore(), nil
+}
+
+// builds one index per named vector field, dense fields and multi vector fields in separate
+// maps, a map is nil when the collection declares no field of its kind
+func newFieldIndexes(cfg CollectionConfig) (map[string]index.VectorIndex, map[string]index.MultiVectorIndex, error) {
+	var indexFactory index.DefaultIndexFactory
+	var fields map[string]index.VectorIndex
+	var multi map[string]index.MultiVectorIndex
+	for _, vf := range cfg.Vectors {
+		indexConfig, err := vf.indexConfig()
+		if err != nil {
+			return nil, nil, fmt.Errorf("index config creation failed for field %s: %w", vf.Name, err)
+		}
+		if vf.MultiVector {
+			idx, err := indexFactory.CreateMultiVectorIndex(indexConfig)
+			if err != nil {
+				return nil, nil, fmt.Errorf("index creation failed for field %s: %w", vf.Name, err)
+			}
+			if multi == nil {
+				multi = make(map[string]index.MultiVectorIndex)
+			}
+			multi[vf.Name] = idx
+			continue
+		}
+		idx, err := indexFactory.CreateIndex(indexConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("index creation failed for field %s: %w", vf.Name, err)
+		}
+		if fields == nil {
+			fields = make(map[string]index.VectorIndex)
+		}
+		fields[vf.Name] = idx
+	}
+	return fields, multi, nil
+}
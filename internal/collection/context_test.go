@@ -0,0 +1,101 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func TestCollection_ContextCancelled(t *testing.T) {
+	c := scrollCollection(t)
+	id, err := c.InsertContext(context.Background(), []float32{1, 0}, nil)
+	if err != nil {
+		t.Fatalf("InsertContext failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.InsertContext(ctx, []float32{0, 1}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("insert: expected context.Canceled, got %v", err)
+	}
+	if n := len(c.ExtToInt()); n != 1 {
+		t.Errorf("cancelled insert must not add a point, got %d points", n)
+	}
+	if err := c.DeleteContext(ctx, id); !errors.Is(err, context.Canceled) {
+		t.Errorf("delete: expected context.Canceled, got %v", err)
+	}
+	if _, ok := c.Get(id); !ok {
+		t.Error("cancelled delete must keep the point")
+	}
+	if _, err := c.SearchContext(ctx, []float32{1, 0}, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("search: expected context.Canceled, got %v", err)
+	}
+	if _, err := c.SearchBatchContext(ctx, [][]float32{{1, 0}}, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("batch: expected context.Canceled, got %v", err)
+	}
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+	if _, err := c.SearchFieldContext(expired, DefaultVectorField, []float32{1, 0}, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("search field: expected context.DeadlineExceeded, got %v", err)
+	}
+
+	results, err := c.SearchContext(context.Background(), []float32{1, 0}, 1)
+	if err != nil || len(results) != 1 || results[0].VecID != id {
+		t.Errorf("expected %s with a live context, got %v, %v", id, results, err)
+	}
+	if err := c.DeleteContext(context.Background(), id); err != nil {
+		t.Errorf("DeleteContext failed: %v", err)
+	}
+}
+
+// context that reports cancellation from its n+1th Err call on, counting the calls
+type cancelAfter struct {
+	context.Context
+	n, calls int
+}
+
+func (c *cancelAfter) Err() error {
+	c.calls++
+	if c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+// Post-condition: a search cancelled after its scan started returns ctx.Err() without results
+// and without checking ctx for every remaining tile.
+func TestCollection_SearchContext_CancelledMidScan(t *testing.T) {
+	const dim = 1024
+	c, err := CreateCollection(CollectionConfig{Name: "wide", Dimension: dim, Metric: types.Cosine, IndexType: types.LinearIndex, DataType: types.Text, ModelName: "t"}, t.TempDir(), wal.SyncOS)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	// 32 vectors of 1024 floats fill one arena tile, 320 make ten tiles
+	vals := make([]float32, dim)
+	for i := range 320 {
+		vals[i%dim] = 1
+		if _, err := c.Insert(vals, nil); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		vals[i%dim] = 0
+	}
+	vals[0] = 1
+
+	ctx := &cancelAfter{Context: context.Background(), n: 2}
+	results, err := c.SearchContext(ctx, vals, 1)
+	if !errors.Is(err, context.Canceled) || len(results) != 0 {
+		t.Fatalf("expected context.Canceled and no results, got %v, %v", results, err)
+	}
+	if ctx.calls >= 10 {
+		t.Errorf("scan kept checking ctx after the cancel, %d checks", ctx.calls)
+	}
+	if results, err := c.SearchContext(context.Background(), vals, 1); err != nil || len(results) != 1 {
+		t.Errorf("expected a result with a live context, got %v, %v", results, err)
+	}
+}
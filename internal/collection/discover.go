@@ -0,0 +1,142 @@
+package collection
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// Discovery ranks points by context pairs instead of plain similarity. A point satisfies a pair
+// when it is more similar to the pair's positive vector than to its negative one. Points are
+// ranked by the number of satisfied pairs first and by similarity to the target second. The
+// ranking compares both keys as they are, the reported score is the count plus the target
+// similarity squashed into (0, 1), which can round to equal values for far apart similarities.
+// Without a target the search only explores the context and ties between equally satisfying
+// points break by id.
+
+// ContextPair is one positive/negative example pair of a discovery search
+type ContextPair struct {
+	Positive []float32
+	Negative []float32
+}
+
+// DiscoverQuery is a discovery search over one dense field, it needs a target or a pair
+type DiscoverQuery struct {
+	Target  []float32
+	Context []ContextPair
+	// dense field searched, DefaultVectorField is the top level vector
+	Field string
+}
+
+// Discover returns the k points matching filter ranked by satisfied context pairs and target
+// similarity
+func (c *Collection) Discover(q DiscoverQuery, k int, filter *Filter) ([]Result, error) {
+	if k <= 0 {
+		return []Result{}, errors.New("invalid input for number of results")
+	}
+	if q.Target == nil && len(q.Context) == 0 {
+		return []Result{}, fmt.Errorf("%w: discover needs a target or a context pair", ErrEmptyQuery)
+	}
+	if err := filter.validate(); err != nil {
+		return []Result{}, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	field, err := c.denseField(q.Field)
+	if err != nil {
+		return []Result{}, err
+	}
+	impl, ok := vector.LookupMetric(field.metric)
+	if !ok {
+		return []Result{}, ErrInvalidMetric
+	}
+	// query vectors in the form the metric scores, normalized where it normalizes
+	var target *vector.Vector
+	if q.Target != nil {
+		if target, err = field.newQuery(q.Target); err != nil {
+			return []Result{}, fmt.Errorf("discover target: %w", err)
+		}
+	}
+	pairs := make([][2]*vector.Vector, len(q.Context))
+	for i, pair := range q.Context {
+		pos, err := field.newQuery(pair.Positive)
+		if err != nil {
+			return []Result{}, fmt.Errorf("context pair %d positive: %w", i, err)
+		}
+		neg, err := field.newQuery(pair.Negative)
+		if err != nil {
+			return []Result{}, fmt.Errorf("context pair %d negative: %w", i, err)
+		}
+		pairs[i] = [2]*vector.Vector{pos, neg}
+	}
+
+	// candidate generation, the neighbours of the target and of every positive vector
+	generators := make([]*vector.Vector, 0, len(pairs)+1)
+	if target != nil {
+		generators = append(generators, target)
+	}
+	for _, pair := range pairs {
+		generators = append(generators, pair[0])
+	}
+	candidates := make(map[int]bool)
+	for _, query := range generators {
+		results, err := c.searchFiltered(field.idx, query, filterOversample*k, filter, nil)
+		if err != nil {
+			return []Result{}, err
+		}
+		for _, res := range results {
+			candidates[res.VecId] = true
+		}
+	}
+
+	// exact rerank of the candidates
+	type ranked struct {
+		res       index.SearchResult
+		satisfied int
+		target    float64
+	}
+	scored := make([]ranked, 0, len(candidates))
+	for id := range candidates {
+		vec, ok := field.idx.Get(id)
+		if !ok {
+			continue
+		}
+		stored := vec.Values()
+		satisfied := 0
+		for _, pair := range pairs {
+			if impl.Score(pair[0].Values(), stored) > impl.Score(pair[1].Values(), stored) {
+				satisfied++
+			}
+		}
+		cand := ranked{res: index.SearchResult{VecId: id, Score: float64(satisfied)}, satisfied: satisfied}
+		if target != nil {
+			cand.target = float64(impl.Score(target.Values(), stored))
+			cand.res.Score += sigmoid(cand.target)
+		}
+		scored = append(scored, cand)
+	}
+	slices.SortFunc(scored, func(a, b ranked) int {
+		if c := cmp.Compare(b.satisfied, a.satisfied); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.target, a.target); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.res.VecId, b.res.VecId)
+	})
+	results := make([]index.SearchResult, 0, min(k, len(scored)))
+	for _, cand := range scored[:min(k, len(scored))] {
+		results = append(results, cand.res)
+	}
+	return c.toResults(results)
+}
+
+// maps any similarity into (0, 1) keeping its order, so it never outweighs a satisfied pair
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
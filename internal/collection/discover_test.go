@@ -0,0 +1,80 @@
+package collection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func TestCollection_Discover(t *testing.T) {
+	c, p, n, a, b, d := recommendCollection(t)
+	pair := ContextPair{Positive: []float32{1, 0}, Negative: []float32{0, 1}}
+
+	// p, a and d satisfy the pair and come first however close n and b are to the target
+	results, err := c.Discover(DiscoverQuery{Target: []float32{0, 1}, Context: []ContextPair{pair}}, 10, nil)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{a, p, d, n, b}) {
+		t.Errorf("expected a, p, d, n, b, got %v", got)
+	}
+	if results[2].Score < 1 || results[3].Score >= 1 {
+		t.Errorf("expected scores >= 1 exactly for points satisfying the pair, got %+v", results)
+	}
+
+	// pure context search, equally satisfying points in insertion order
+	results, err = c.Discover(DiscoverQuery{Context: []ContextPair{pair}}, 3, nil)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{p, a, d}) {
+		t.Errorf("context only: expected p, a, d, got %v", got)
+	}
+
+	// target only ranks like a plain search
+	results, _ = c.Discover(DiscoverQuery{Target: []float32{0, 1}}, 2, nil)
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{n, b}) {
+		t.Errorf("target only: expected n, b, got %v", got)
+	}
+
+	filter := &Filter{Must: []Condition{{Key: "kind", MatchAny: []any{"away", "far"}}}}
+	results, _ = c.Discover(DiscoverQuery{Target: []float32{0, 1}, Context: []ContextPair{pair}}, 10, filter)
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{d, b}) {
+		t.Errorf("filtered: expected d, b, got %v", got)
+	}
+}
+
+// Post-condition: target similarity still orders points with equal satisfied pairs when the
+// squashed score can no longer tell them apart, as with far away Manhattan distances.
+func TestCollection_Discover_Manhattan(t *testing.T) {
+	c, err := CreateCollection(CollectionConfig{Name: "manhattan", Dimension: 2, Metric: types.Manhattan, IndexType: types.LinearIndex, DataType: types.Text, ModelName: "t"}, t.TempDir(), wal.SyncOS)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	// the farther point gets the lower internal id
+	far, _ := c.Insert([]float32{61, 0}, nil)
+	near, _ := c.Insert([]float32{51, 0}, nil)
+	pair := ContextPair{Positive: []float32{100, 0}, Negative: []float32{-100, 0}}
+	results, err := c.Discover(DiscoverQuery{Target: []float32{1, 0}, Context: []ContextPair{pair}}, 10, nil)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{near, far}) {
+		t.Errorf("expected the point at distance 50 before the one at 60, got %v", got)
+	}
+}
+
+func TestCollection_Discover_Errors(t *testing.T) {
+	c, _, _, _, _, _ := recommendCollection(t)
+	if _, err := c.Discover(DiscoverQuery{}, 3, nil); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("expected ErrEmptyQuery, got %v", err)
+	}
+	bad := ContextPair{Positive: []float32{1, 0}, Negative: []float32{1}}
+	if _, err := c.Discover(DiscoverQuery{Context: []ContextPair{bad}}, 3, nil); !errors.Is(err, ErrInvalidDimension) {
+		t.Errorf("expected ErrInvalidDimension, got %v", err)
+	}
+}
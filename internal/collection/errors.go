@@ -12,4 +12,20 @@ var (
 	ErrInvalidModelName      = errors.New("invalid model name type")
 	ErrInternalIDCollision   = errors.New("internal id collision")
 	ErrInvalidCollectionName = errors.New("invalid collection name")
+	ErrInvalidQuantization   = errors.New("invalid quantization config")
+	ErrInvalidPrecision      = errors.New("invalid vector precision")
+	ErrInvalidPayloadStorage = errors.New("invalid payload storage")
+	ErrInvalidPayloadSchema  = errors.New("invalid payload schema")
+	ErrInvalidPayload        = errors.New("payload does not match the collection schema")
+	ErrPayloadNotObject      = errors.New("payload is not a JSON object")
+	ErrInvalidTTL            = errors.New("invalid time to live")
+	ErrInvalidFilter         = errors.New("invalid filter")
+	ErrInvalidCursor         = errors.New("invalid scroll cursor")
+	ErrInvalidSparseConfig   = errors.New("invalid sparse config")
+	ErrInvalidVectorField    = errors.New("invalid vector field")
+	ErrUnknownVectorField    = errors.New("unknown vector field")
+	ErrEmptyQuery            = errors.New("query has neither a dense nor a sparse part")
+	ErrVectorKindMismatch    = errors.New("operation does not match the collection vector kind (dense or sparse)")
+	ErrSnapshotReleased      = errors.New("snapshot released")
+	ErrInvalidBackup         = errors.New("invalid backup archive")
 )
@@ -0,0 +1,125 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// Named vector fields give one point several dense embeddings, each with its own dimension,
+// metric and index. The top level vector of the config stays the default field and is
+// required on every insert, named fields are optional per point. All vectors of a point
+// share its external and internal ids and are logged in one WAL insert record.
+
+// DefaultVectorField addresses the collection's top level vector in field based APIs
+const DefaultVectorField = ""
+
+// a dense field resolved by name, the shape its vectors are validated against
+type denseField struct {
+	idx       index.VectorIndex
+	dimension int
+	metric    types.SimilarityMetric
+	precision types.Precision
+}
+
+// resolves a dense field, DefaultVectorField is the top level vector
+func (c *Collection) denseField(name string) (denseField, error) {
+	if name == DefaultVectorField {
+		if c.index == nil {
+			return denseField{}, ErrVectorKindMismatch
+		}
+		return denseField{c.index, c.config.Dimension, c.config.Metric, c.config.Precision}, nil
+	}
+	for _, vf := range c.config.Vectors {
+		if vf.Name == name {
+			if vf.MultiVector {
+				return denseField{}, fmt.Errorf("%w: %s is a multi vector field", ErrVectorKindMismatch, name)
+			}
+			return denseField{c.fields[name], vf.Dimension, vf.Metric, vf.Precision}, nil
+		}
+	}
+	return denseField{}, fmt.Errorf("%w: %s", ErrUnknownVectorField, name)
+}
+
+// stored vector for the field, rounded to its precision
+func (f denseField) newVector(values []float32) (*vector.Vector, error) {
+	if len(values) != f.dimension {
+		return nil, ErrInvalidDimension
+	}
+	return vector.NewVectorForMetric(values, f.dimension, f.metric, f.precision)
+}
+
+// query vectors are never rounded, only stored ones are
+func (f denseField) newQuery(values []float32) (*vector.Vector, error) {
+	if len(values) != f.dimension {
+		return nil, ErrInvalidDimension
+	}
+	return vector.NewVectorForMetric(values, f.dimension, f.metric, types.Float32Precision)
+}
+
+// values the WAL logs for a vector of this field, the raw input for float32 fields and the
+// constructed (normalized, in range) values for half precision ones
+func (f denseField) walValues(raw []float32, vec *vector.Vector) []float32 {
+	if f.precision == types.Float32Precision {
+		return raw
+	}
+	return vec.Values()
+}
+
+// InsertWithVectors adds a point with its default vector and vectors for any of the named fields
+func (c *Collection) InsertWithVectors(vecVals []float32, named map[string][]float32, payload any) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.insertPoint(pointInput{dense: vecVals, named: named}, payload)
+}
+
+// SearchField searches one dense field, DefaultVectorField searches the top level vector like Search
+func (c *Collection) SearchField(field string, queryVals []float32, k int) ([]Result, error) {
+	return c.SearchFieldContext(context.Background(), field, queryVals, k)
+}
+
+// SearchFieldContext is SearchField bounded by ctx like SearchContext
+func (c *Collection) SearchFieldContext(ctx context.Context, field string, queryVals []float32, k int) ([]Result, error) {
+	if err := ctx.Err(); err != nil {
+		return []Result{}, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.searchField(ctx, field, queryVals, k)
+}
+
+// caller holds c.mu
+func (c *Collection) searchField(ctx context.Context, name string, queryVals []float32, k int) ([]Result, error) {
+	field, err := c.denseField(name)
+	if err != nil {
+		return []Result{}, err
+	}
+	queryVector, err := field.newQuery(queryVals)
+	if err != nil {
+		return []Result{}, err
+	}
+	// expired points not reaped yet are fetched and dropped
+	expired := c.expiredIDs()
+	idxResult, err := field.idx.SearchContext(ctx, queryVector, k+len(expired))
+	if err != nil {
+		return []Result{}, err
+	}
+	return c.toResults(dropExpired(idxResult, expired, k))
+}
+
+// translates index results to external ids, caller holds c.mu
+func (c *Collection) toResults(idxResult []index.SearchResult) ([]Result, error) {
+	colResult := make([]Result, len(idxResult))
+	for i, val := range idxResult {
+		extID, ok := c.intToExt[val.VecId]
+		if !ok {
+			return []Result{}, errors.New("id doesn't exist internal corruption")
+		}
+		colResult[i] = Result{extID, val.Score}
+	}
+	return colResult, nil
+}
@@ -0,0 +1,122 @@
+package collection
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func namedFieldsConfig() CollectionConfig {
+	return CollectionConfig{
+		Name:      "docs",
+		Dimension: 2,
+		Metric:    types.Cosine,
+		IndexType: types.LinearIndex,
+		DataType:  types.Text,
+		ModelName: "model",
+		Vectors: []VectorField{
+			{Name: "body", Dimension: 3, Metric: types.Euclidean, IndexType: types.LinearIndex},
+			{Name: "image", Dimension: 4, Metric: types.Cosine, IndexType: types.LinearIndex, Precision: types.Float16Precision},
+		},
+	}
+}
+
+func TestCreateCollection_VectorFieldValidation(t *testing.T) {
+	bad := [][]VectorField{
+		{{Name: "", Dimension: 3, Metric: types.Cosine, IndexType: types.LinearIndex}},
+		{{Name: "a", Dimension: 3, Metric: types.Cosine, IndexType: types.LinearIndex}, {Name: "a", Dimension: 2, Metric: types.Cosine, IndexType: types.LinearIndex}},
+		{{Name: "a", Dimension: 0, Metric: types.Cosine, IndexType: types.LinearIndex}},
+		{{Name: "a", Dimension: 3, Metric: types.Cosine, IndexType: types.InvertedIndex}},
+		{{Name: "a", Dimension: 3, Metric: types.Euclidean, IndexType: types.LinearIndex,
+			Quantization: index.QuantizationConfig{Type: types.BinaryQuantization}}},
+	}
+	for i, fields := range bad {
+		cfg := namedFieldsConfig()
+		cfg.Vectors = fields
+		if _, err := CreateCollection(cfg, t.TempDir(), wal.SyncAlways); !errors.Is(err, ErrInvalidVectorField) {
+			t.Errorf("case %d: expected ErrInvalidVectorField, got %v", i, err)
+		}
+	}
+}
+
+func TestCollection_NamedFields_InsertAndSearch(t *testing.T) {
+	c, err := CreateCollection(namedFieldsConfig(), t.TempDir(), wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+
+	a, err := c.InsertWithVectors([]float32{1, 0}, map[string][]float32{
+		"body":  {1, 0, 0},
+		"image": {1, 0, 0, 0},
+	}, nil)
+	if err != nil {
+		t.Fatalf("InsertWithVectors failed: %v", err)
+	}
+	// named fields are optional per point
+	b, err := c.InsertWithVectors([]float32{0, 1}, map[string][]float32{"body": {1, 1, 1}}, nil)
+	if err != nil {
+		t.Fatalf("InsertWithVectors failed: %v", err)
+	}
+
+	results, err := c.SearchField("body", []float32{2, 2, 2}, 5)
+	if err != nil {
+		t.Fatalf("SearchField failed: %v", err)
+	}
+	if len(results) != 2 || results[0].VecID != b {
+		t.Errorf("body: expected %s first, got %+v", b, results)
+	}
+	results, _ = c.SearchField("image", []float32{1, 0, 0, 0}, 5)
+	if len(results) != 1 || results[0].VecID != a {
+		t.Errorf("image: expected only %s, got %+v", a, results)
+	}
+	results, _ = c.SearchField(DefaultVectorField, []float32{0, 1}, 1)
+	if results[0].VecID != b {
+		t.Errorf("default field: expected %s, got %+v", b, results)
+	}
+
+	if _, err := c.SearchField("title", []float32{1}, 1); !errors.Is(err, ErrUnknownVectorField) {
+		t.Errorf("expected ErrUnknownVectorField, got %v", err)
+	}
+	if _, err := c.InsertWithVectors([]float32{1, 0}, map[string][]float32{"title": {1}}, nil); !errors.Is(err, ErrUnknownVectorField) {
+		t.Errorf("expected ErrUnknownVectorField on insert, got %v", err)
+	}
+	if _, err := c.InsertWithVectors([]float32{1, 0}, map[string][]float32{"body": {1, 2}}, nil); !errors.Is(err, ErrInvalidDimension) {
+		t.Errorf("expected ErrInvalidDimension for short body vector, got %v", err)
+	}
+	// failed inserts must not leave a partial point behind
+	if c.IDCounter() != 2 {
+		t.Errorf("expected id counter 2, got %d", c.IDCounter())
+	}
+}
+
+func TestCollection_NamedFields_DeleteAndRecovery(t *testing.T) {
+	rootDir := t.TempDir()
+	c, err := CreateCollection(namedFieldsConfig(), rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	keep, _ := c.InsertWithVectors([]float32{1, 0}, map[string][]float32{"image": {0, 1, 0, 0}}, nil)
+	gone, _ := c.InsertWithVectors([]float32{0, 1}, map[string][]float32{"image": {0, 1, 0.1, 0}}, nil)
+	c.Delete(gone)
+	c.Close()
+
+	reopened, err := OpenCollection(rootDir, "docs", wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	defer reopened.Close()
+	results, err := reopened.SearchField("image", []float32{0, 1, 0, 0}, 5)
+	if err != nil {
+		t.Fatalf("SearchField failed: %v", err)
+	}
+	if len(results) != 1 || results[0].VecID != keep {
+		t.Errorf("unexpected image results after replay %+v", results)
+	}
+	if results, _ := reopened.SearchField("body", []float32{1, 1, 1}, 5); len(results) != 0 {
+		t.Errorf("no point has a body vector, got %+v", results)
+	}
+}
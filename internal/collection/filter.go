@@ -0,0 +1,338 @@
+package collection
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"strings"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// Filters select points by their payload. A point matches a Filter when every Must condition
+// holds, at least one Should condition holds (if there are any) and no MustNot condition holds.
+// Payloads that are not JSON objects only match filters without conditions.
+
+// Filter combines payload conditions, a nil or empty Filter matches every point
+type Filter struct {
+	Must    []Condition
+	Should  []Condition
+	MustNot []Condition
+}
+
+// Condition tests one payload key, exactly one of Match, MatchAny, Range or Filter is set.
+// Key is a dotted path into nested objects, e.g. "author.name". Conditions on a list value
+// hold when any element satisfies them
+type Condition struct {
+	Key string
+	// value equals Match (string, number or bool)
+	Match any
+	// value equals any of MatchAny
+	MatchAny []any
+	// numeric value inside Range
+	Range *Range
+	// nested filter, Key is ignored
+	Filter *Filter
+}
+
+// Range bounds a numeric payload value, nil bounds are open
+type Range struct {
+	Gt, Gte, Lt, Lte *float64
+}
+
+// checks the shape of every condition before any point is evaluated
+func (f *Filter) validate() error {
+	if f == nil {
+		return nil
+	}
+	for _, conds := range [][]Condition{f.Must, f.Should, f.MustNot} {
+		for _, cond := range conds {
+			if err := cond.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cond Condition) validate() error {
+	set := 0
+	if cond.Match != nil {
+		set++
+		if !isScalar(cond.Match) {
+			return fmt.Errorf("%w: key %s: match value must be a string, number or bool, got %T", ErrInvalidFilter, cond.Key, cond.Match)
+		}
+	}
+	if cond.MatchAny != nil {
+		set++
+		for _, v := range cond.MatchAny {
+			if !isScalar(v) {
+				return fmt.Errorf("%w: key %s: match any values must be strings, numbers or bools, got %T", ErrInvalidFilter, cond.Key, v)
+			}
+		}
+	}
+	if cond.Range != nil {
+		set++
+	}
+	if cond.Filter != nil {
+		set++
+		if err := cond.Filter.validate(); err != nil {
+			return err
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("%w: key %s: a condition needs exactly one of Match, MatchAny, Range or Filter", ErrInvalidFilter, cond.Key)
+	}
+	if cond.Filter == nil && cond.Key == "" {
+		return fmt.Errorf("%w: condition without key", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// matches reports whether a payload satisfies the filter
+func (f *Filter) matches(payload any) bool {
+	if f == nil || (len(f.Must) == 0 && len(f.Should) == 0 && len(f.MustNot) == 0) {
+		return true
+	}
+	obj := filterObject(payload)
+	return f.matchesObject(obj)
+}
+
+func (f *Filter) matchesObject(obj map[string]any) bool {
+	for _, cond := range f.Must {
+		if !cond.matches(obj) {
+			return false
+		}
+	}
+	if len(f.Should) > 0 {
+		matched := false
+		for _, cond := range f.Should {
+			if cond.matches(obj) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, cond := range f.MustNot {
+		if cond.matches(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cond Condition) matches(obj map[string]any) bool {
+	if cond.Filter != nil {
+		return cond.Filter.matchesObject(obj)
+	}
+	val, ok := lookupKey(obj, cond.Key)
+	if !ok {
+		return false
+	}
+	// list values match when any element does
+	if list, ok := val.([]any); ok {
+		for _, item := range list {
+			if cond.matchesValue(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return cond.matchesValue(val)
+}
+
+func (cond Condition) matchesValue(val any) bool {
+	switch {
+	case cond.Match != nil:
+		return scalarEqual(val, cond.Match)
+	case cond.MatchAny != nil:
+		for _, want := range cond.MatchAny {
+			if scalarEqual(val, want) {
+				return true
+			}
+		}
+		return false
+	case cond.Range != nil:
+		n, ok := toFloat(val)
+		return ok && cond.Range.contains(n)
+	}
+	return false
+}
+
+func (r *Range) contains(n float64) bool {
+	return (r.Gt == nil || n > *r.Gt) &&
+		(r.Gte == nil || n >= *r.Gte) &&
+		(r.Lt == nil || n < *r.Lt) &&
+		(r.Lte == nil || n <= *r.Lte)
+}
+
+// generic JSON view of a payload, nil when it is not an object
+func filterObject(payload any) map[string]any {
+	if obj, ok := payload.(map[string]any); ok {
+		return obj
+	}
+	// typed Go values (structs, map[string]string, ...) of in memory payloads
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	var obj map[string]any
+	if err := decodeExact(raw, &obj); err != nil {
+		return nil
+	}
+	return obj
+}
+
+// follows a dotted path through nested objects
+func lookupKey(obj map[string]any, key string) (any, bool) {
+	var val any = obj
+	for part := range strings.SplitSeq(key, ".") {
+		m, ok := val.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if val, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	// typed lists of schema payloads behave like generic JSON arrays
+	if strs, ok := val.([]string); ok {
+		list := make([]any, len(strs))
+		for i, s := range strs {
+			list[i] = s
+		}
+		return list, true
+	}
+	return val, true
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, bool:
+		return true
+	}
+	_, ok := toFloat(v)
+	return ok
+}
+
+// equality across the number representations payloads use (float64, int64, json.Number, ...)
+func scalarEqual(a, b any) bool {
+	if ai, ok := toInt(a); ok {
+		if bi, ok := toInt(b); ok {
+			return ai == bi
+		}
+	}
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	return a == b
+}
+
+// exact integer value of a number, so int64 ids compare without float rounding
+func toInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) < 1<<53 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// default oversampling of filtered searches, candidates fetched per wanted result
+const filterOversample = 4
+
+// searches idx and keeps only points matching filter and not in exclude, the index is asked for
+// more candidates until k survive or it has nothing left. Expired points are always excluded.
+// Caller holds c.mu
+func (c *Collection) searchFiltered(idx index.VectorIndex, query *vector.Vector, k int, filter *Filter, exclude map[int]bool) ([]index.SearchResult, error) {
+	if expired := c.expiredIDs(); len(expired) > 0 {
+		maps.Copy(expired, exclude)
+		exclude = expired
+	}
+	if filter == nil && len(exclude) == 0 {
+		return idx.Search(query, k)
+	}
+	fetch := k + len(exclude)
+	if filter != nil {
+		fetch = filterOversample * fetch
+	}
+	for {
+		candidates, err := idx.Search(query, fetch)
+		if err != nil {
+			return nil, err
+		}
+		kept, err := c.keepMatching(candidates, k, filter, exclude)
+		if err != nil {
+			return nil, err
+		}
+		// a short answer means the index ran out of points
+		if len(kept) == k || len(candidates) < fetch {
+			return kept, nil
+		}
+		fetch *= filterOversample
+	}
+}
+
+// the first k results matching filter and not in exclude, in order. Caller holds c.mu
+func (c *Collection) keepMatching(results []index.SearchResult, k int, filter *Filter, exclude map[int]bool) ([]index.SearchResult, error) {
+	kept := make([]index.SearchResult, 0, min(k, len(results)))
+	for _, res := range results {
+		if len(kept) == k {
+			break
+		}
+		if exclude[res.VecId] {
+			continue
+		}
+		if filter != nil {
+			extID, ok := c.intToExt[res.VecId]
+			if !ok {
+				return nil, errors.New("id doesn't exist internal corruption")
+			}
+			payload, _, err := c.payloads.Get(extID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read payload of %s: %w", extID, err)
+			}
+			if !filter.matches(payload) {
+				continue
+			}
+		}
+		kept = append(kept, res)
+	}
+	return kept, nil
+}
@@ -0,0 +1,69 @@
+package collection
+
+import (
+	"errors"
+	"testing"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+func TestFilter_Matches(t *testing.T) {
+	payload := map[string]any{
+		"city":   "Pune",
+		"price":  float64(120),
+		"stock":  int64(1<<60 + 1),
+		"tags":   []string{"new", "sale"},
+		"author": map[string]any{"name": "Asha"},
+	}
+	cases := []struct {
+		name   string
+		filter *Filter
+		want   bool
+	}{
+		{"nil", nil, true},
+		{"match", &Filter{Must: []Condition{{Key: "city", Match: "Pune"}}}, true},
+		{"match mismatch", &Filter{Must: []Condition{{Key: "city", Match: "Delhi"}}}, false},
+		{"int match float", &Filter{Must: []Condition{{Key: "price", Match: 120}}}, true},
+		{"exact int64", &Filter{Must: []Condition{{Key: "stock", Match: int64(1 << 60)}}}, false},
+		{"list element", &Filter{Must: []Condition{{Key: "tags", Match: "sale"}}}, true},
+		{"match any", &Filter{Must: []Condition{{Key: "city", MatchAny: []any{"Delhi", "Pune"}}}}, true},
+		{"range", &Filter{Must: []Condition{{Key: "price", Range: &Range{Gte: ptr(100), Lt: ptr(120)}}}}, false},
+		{"range inclusive", &Filter{Must: []Condition{{Key: "price", Range: &Range{Lte: ptr(120)}}}}, true},
+		{"nested key", &Filter{Must: []Condition{{Key: "author.name", Match: "Asha"}}}, true},
+		{"missing key", &Filter{Must: []Condition{{Key: "author.age", Range: &Range{Gt: ptr(1)}}}}, false},
+		{"should", &Filter{Should: []Condition{{Key: "city", Match: "Delhi"}, {Key: "tags", Match: "new"}}}, true},
+		{"should none", &Filter{Should: []Condition{{Key: "city", Match: "Delhi"}}}, false},
+		{"must not", &Filter{MustNot: []Condition{{Key: "tags", Match: "sale"}}}, false},
+		{"nested filter", &Filter{Must: []Condition{{Filter: &Filter{MustNot: []Condition{{Key: "city", Match: "Delhi"}}}}}}, true},
+	}
+	for _, tc := range cases {
+		if err := tc.filter.validate(); err != nil {
+			t.Fatalf("%s: unexpected validation error %v", tc.name, err)
+		}
+		if got := tc.filter.matches(payload); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+	// non object payloads only match empty filters
+	if (&Filter{MustNot: []Condition{{Key: "a", Match: 1}}}).matches("text") != true {
+		t.Error("must not on a non object payload should hold")
+	}
+	if (&Filter{Must: []Condition{{Key: "a", Match: 1}}}).matches("text") {
+		t.Error("must on a non object payload should not hold")
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	bad := []*Filter{
+		{Must: []Condition{{Key: "a"}}},
+		{Must: []Condition{{Key: "a", Match: 1, Range: &Range{}}}},
+		{Must: []Condition{{Match: 1}}},
+		{Must: []Condition{{Key: "a", Match: []int{1}}}},
+		{Should: []Condition{{Filter: &Filter{Must: []Condition{{Key: "b"}}}}}},
+	}
+	for i, f := range bad {
+		if err := f.validate(); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("case %d: expected ErrInvalidFilter, got %v", i, err)
+		}
+	}
+}
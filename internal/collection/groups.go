@@ -0,0 +1,112 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+)
+
+// Group is the hits of one payload value in a grouped search, best hit first
+type Group struct {
+	// value of the group by key, numbers as int64 or float64 like facet values
+	Key  any
+	Hits []Result
+}
+
+// SearchGroups searches the default vector and returns up to groups groups of at most groupSize
+// hits each, grouped by the payload value at groupBy (a dotted path). Groups are ordered by
+// their best hit, points without the key are skipped and a point with a list value joins the
+// group of every element. The index is searched deeper until the groups are full or it has no
+// more points.
+func (c *Collection) SearchGroups(queryVals []float32, groupBy string, groupSize, groups int) ([]Group, error) {
+	if groupBy == "" {
+		return nil, errors.New("group by key is empty")
+	}
+	if groupSize <= 0 || groups <= 0 {
+		return nil, errors.New("invalid input for group size or number of groups")
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	field, err := c.denseField(DefaultVectorField)
+	if err != nil {
+		return nil, err
+	}
+	query, err := field.newQuery(queryVals)
+	if err != nil {
+		return nil, err
+	}
+	fetch := groupSize * groups
+	for {
+		results, err := field.idx.Search(query, fetch)
+		if err != nil {
+			return nil, err
+		}
+		grouped, full, err := c.groupResults(results, groupBy, groupSize, groups)
+		if err != nil {
+			return nil, err
+		}
+		// a short answer means the index ran out of points
+		if full || len(results) < fetch {
+			return grouped, nil
+		}
+		fetch *= filterOversample
+	}
+}
+
+// groups results in order, the first groups distinct values seen are the best groups.
+// Reports whether all of them are full. Caller holds c.mu
+func (c *Collection) groupResults(results []index.SearchResult, groupBy string, groupSize, groups int) ([]Group, bool, error) {
+	grouped := make([]Group, 0, groups)
+	position := make(map[any]int, groups)
+	for _, res := range results {
+		if c.expired(res.VecId) {
+			continue
+		}
+		extID, ok := c.intToExt[res.VecId]
+		if !ok {
+			return nil, false, errors.New("id doesn't exist internal corruption")
+		}
+		payload, _, err := c.payloads.Get(extID)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read payload of %s: %w", extID, err)
+		}
+		val, ok := lookupKey(filterObject(payload), groupBy)
+		if !ok {
+			continue
+		}
+		values, isList := val.([]any)
+		if !isList {
+			values = []any{val}
+		}
+		for _, v := range values {
+			key, ok := facetKey(v)
+			if !ok {
+				continue
+			}
+			i, seen := position[key]
+			if !seen {
+				if len(grouped) == groups {
+					continue
+				}
+				i = len(grouped)
+				position[key] = i
+				grouped = append(grouped, Group{Key: key})
+			}
+			// a list naming a value twice still adds the point once
+			hits := grouped[i].Hits
+			if len(hits) < groupSize && (len(hits) == 0 || hits[len(hits)-1].VecID != extID) {
+				grouped[i].Hits = append(hits, Result{extID, res.Score})
+			}
+		}
+	}
+	if len(grouped) < groups {
+		return grouped, false, nil
+	}
+	for _, g := range grouped {
+		if len(g.Hits) < groupSize {
+			return grouped, false, nil
+		}
+	}
+	return grouped, true, nil
+}
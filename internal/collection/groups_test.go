@@ -0,0 +1,71 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ids of every group, in order
+func groupIDs(groups []Group) map[any][]string {
+	ids := make(map[any][]string, len(groups))
+	for _, g := range groups {
+		ids[g.Key] = resultIDs(g.Hits)
+	}
+	return ids
+}
+
+func TestCollection_SearchGroups(t *testing.T) {
+	c := scrollCollection(t)
+	insert := func(vals []float32, payload any) string {
+		id, err := c.Insert(vals, payload)
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		return id
+	}
+	// doc 1 owns every chunk of the first searches, the groups need a deeper one
+	var doc1 []string
+	for i := range 10 {
+		doc1 = append(doc1, insert([]float32{1, 0.01 * float32(i)}, map[string]any{"doc_id": 1, "tags": []string{"a", "b"}}))
+	}
+	insert([]float32{1, 0.2}, map[string]any{"title": "no doc"})
+	doc2a := insert([]float32{1, 0.5}, map[string]any{"doc_id": 2, "tags": []string{"b", "c"}})
+	doc2b := insert([]float32{1, 0.51}, map[string]any{"doc_id": 2})
+	insert([]float32{1, 0.52}, map[string]any{"doc_id": 2})
+	doc3 := insert([]float32{0, 1}, map[string]any{"doc_id": 3.0})
+
+	groups, err := c.SearchGroups([]float32{1, 0}, "doc_id", 2, 2)
+	if err != nil {
+		t.Fatalf("SearchGroups failed: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Key != int64(1) || groups[1].Key != int64(2) {
+		t.Fatalf("expected groups 1 then 2, got %+v", groups)
+	}
+	want := map[any][]string{int64(1): doc1[:2], int64(2): {doc2a, doc2b}}
+	if got := groupIDs(groups); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if groups[0].Hits[0].Score < groups[0].Hits[1].Score {
+		t.Errorf("expected best hit first, got %+v", groups[0].Hits)
+	}
+
+	// doc 3 has a single chunk, its group stays short once the index is exhausted
+	groups, _ = c.SearchGroups([]float32{1, 0}, "doc_id", 2, 5)
+	if len(groups) != 3 || !reflect.DeepEqual(resultIDs(groups[2].Hits), []string{doc3}) {
+		t.Errorf("expected a third group with doc 3 only, got %+v", groups)
+	}
+
+	// a list value puts the point in the group of every element
+	groups, _ = c.SearchGroups([]float32{1, 0}, "tags", 1, 3)
+	want = map[any][]string{"a": doc1[:1], "b": doc1[:1], "c": {doc2a}}
+	if got := groupIDs(groups); !reflect.DeepEqual(got, want) {
+		t.Errorf("tags: expected %v, got %v", want, got)
+	}
+
+	if _, err := c.SearchGroups([]float32{1, 0}, "", 1, 1); err == nil {
+		t.Error("expected an error for an empty group by key")
+	}
+	if _, err := c.SearchGroups([]float32{1, 0}, "doc_id", 0, 1); err == nil {
+		t.Error("expected an error for group size 0")
+	}
+}
@@ -0,0 +1,131 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+)
+
+// Hybrid collections are dense collections with a SparseConfig, every point has a dense
+// vector and may also carry sparse term weights. SearchHybrid ranks both sides
+// independently and fuses the two rankings into one.
+
+// FusionMethod selects how SearchHybrid merges the dense and sparse rankings
+type FusionMethod int
+
+const (
+	// reciprocal rank fusion, zero value, only ranks matter
+	FusionRRF FusionMethod = iota
+	// min-max normalized scores summed with DenseWeight and 1-DenseWeight
+	FusionWeighted
+)
+
+// HybridQuery is one search over both representations, either part may be left empty
+// to search only the other one
+type HybridQuery struct {
+	// dense field the Dense part searches, DefaultVectorField is the top level vector
+	Field         string
+	Dense         []float32
+	SparseIndices []uint32
+	SparseValues  []float32
+	Fusion        FusionMethod
+	// k constant of FusionRRF, zero uses index.DefaultRRFConstant
+	RRFConstant int
+	// weight of the dense ranking for FusionWeighted in [0,1], the sparse one gets the rest.
+	// nil weighs both sides 0.5, 0 ranks by sparse scores and 1 by dense scores alone
+	DenseWeight *float64
+	// candidates fetched from each side before fusion, zero means 4*k
+	Prefetch int
+}
+
+// default candidates per side as a multiple of k, fusion can only promote what was fetched
+const defaultHybridPrefetch = 4
+
+// dense weight of FusionWeighted when the query leaves DenseWeight nil
+const defaultDenseWeight = 0.5
+
+// InsertHybrid adds a point with both a dense vector and sparse term weights in one WAL record
+func (c *Collection) InsertHybrid(vecVals []float32, sparseIndices []uint32, sparseValues []float32, payload any) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index == nil || c.sparse == nil {
+		return "", ErrVectorKindMismatch
+	}
+	return c.insertPoint(pointInput{dense: vecVals, sparseIndices: sparseIndices, sparseValues: sparseValues}, payload)
+}
+
+// SearchHybrid searches the dense and sparse indexes of a hybrid collection and fuses the rankings,
+// Result scores are fused scores, not similarities
+func (c *Collection) SearchHybrid(q HybridQuery, k int) ([]Result, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.index == nil || c.sparse == nil {
+		return []Result{}, ErrVectorKindMismatch
+	}
+	if k <= 0 {
+		return []Result{}, errors.New("invalid input for number of results")
+	}
+	hasDense := len(q.Dense) > 0
+	hasSparse := len(q.SparseIndices) > 0 || len(q.SparseValues) > 0
+	if !hasDense && !hasSparse {
+		return []Result{}, ErrEmptyQuery
+	}
+	denseWeight := defaultDenseWeight
+	if q.DenseWeight != nil {
+		denseWeight = *q.DenseWeight
+	}
+	if denseWeight < 0 || denseWeight > 1 {
+		return []Result{}, fmt.Errorf("dense weight %v outside [0,1]", denseWeight)
+	}
+	prefetch := q.Prefetch
+	if prefetch <= 0 {
+		prefetch = defaultHybridPrefetch * k
+	}
+	prefetch = max(prefetch, k)
+	// expired points not reaped yet are fetched and dropped after fusion
+	expired := c.expiredIDs()
+	prefetch += len(expired)
+
+	var lists [][]index.SearchResult
+	var weights []float64
+	if hasDense {
+		field, err := c.denseField(q.Field)
+		if err != nil {
+			return []Result{}, err
+		}
+		query, err := field.newQuery(q.Dense)
+		if err != nil {
+			return []Result{}, err
+		}
+		denseResult, err := field.idx.Search(query, prefetch)
+		if err != nil {
+			return []Result{}, err
+		}
+		lists = append(lists, denseResult)
+		weights = append(weights, denseWeight)
+	}
+	if hasSparse {
+		query, err := c.newSparseVector(q.SparseIndices, q.SparseValues)
+		if err != nil {
+			return []Result{}, err
+		}
+		sparseResult, err := c.sparse.Search(query, prefetch)
+		if err != nil {
+			return []Result{}, err
+		}
+		lists = append(lists, sparseResult)
+		weights = append(weights, 1-denseWeight)
+	}
+
+	var fused []index.SearchResult
+	switch q.Fusion {
+	case FusionRRF:
+		fused = index.FuseRRF(lists, q.RRFConstant)
+	case FusionWeighted:
+		fused = index.FuseWeighted(lists, weights)
+	default:
+		return []Result{}, fmt.Errorf("invalid fusion method %d", q.Fusion)
+	}
+	return c.toResults(dropExpired(fused, expired, k))
+}
@@ -0,0 +1,138 @@
+package collection
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func hybridConfig() CollectionConfig {
+	return CollectionConfig{
+		Name:      "hybrid",
+		Dimension: 2,
+		Metric:    types.Cosine,
+		IndexType: types.LinearIndex,
+		DataType:  types.Text,
+		ModelName: "model",
+		Sparse:    &SparseConfig{Vocabulary: 1000},
+	}
+}
+
+func TestCreateCollection_SparseConfigValidation(t *testing.T) {
+	bad := []*SparseConfig{
+		{Vocabulary: 0},
+		{Vocabulary: 10, Metric: types.Euclidean},
+	}
+	for _, sc := range bad {
+		cfg := hybridConfig()
+		cfg.Sparse = sc
+		if _, err := CreateCollection(cfg, t.TempDir(), wal.SyncAlways); !errors.Is(err, ErrInvalidSparseConfig) {
+			t.Errorf("sparse config %+v: expected ErrInvalidSparseConfig, got %v", sc, err)
+		}
+	}
+	cfg := hybridConfig()
+	cfg.IndexType = types.InvertedIndex
+	if _, err := CreateCollection(cfg, t.TempDir(), wal.SyncAlways); !errors.Is(err, ErrInvalidSparseConfig) {
+		t.Errorf("expected ErrInvalidSparseConfig for sparse collection with sparse config, got %v", err)
+	}
+}
+
+// dense ranks a, mid, b and sparse ranks b, a, mid
+func setupHybridCollection(t *testing.T, rootDir string) (*Collection, [3]string) {
+	t.Helper()
+	c, err := CreateCollection(hybridConfig(), rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	var ids [3]string
+	ids[0], err = c.InsertHybrid([]float32{1, 0}, []uint32{1}, []float32{2}, nil)
+	if err != nil {
+		t.Fatalf("InsertHybrid failed: %v", err)
+	}
+	ids[1], _ = c.InsertHybrid([]float32{0, 1}, []uint32{1, 2}, []float32{5, 1}, nil)
+	ids[2], _ = c.InsertHybrid([]float32{1, 0.5}, []uint32{1}, []float32{0.1}, nil)
+	return c, ids
+}
+
+func TestCollection_SearchHybrid(t *testing.T) {
+	c, ids := setupHybridCollection(t, t.TempDir())
+	defer c.Close()
+	a, b, mid := ids[0], ids[1], ids[2]
+	q := HybridQuery{Dense: []float32{1, 0}, SparseIndices: []uint32{1}, SparseValues: []float32{1}}
+
+	results, err := c.SearchHybrid(q, 3)
+	if err != nil {
+		t.Fatalf("SearchHybrid failed: %v", err)
+	}
+	// a is first and second, b first and third, mid second and third
+	if len(results) != 3 || results[0].VecID != a || results[1].VecID != b || results[2].VecID != mid {
+		t.Errorf("RRF: expected a, b, mid, got %+v", results)
+	}
+
+	q.Fusion = FusionWeighted
+	weight := 0.9
+	q.DenseWeight = &weight
+	results, _ = c.SearchHybrid(q, 1)
+	if len(results) != 1 || results[0].VecID != a {
+		t.Errorf("dense weighted: expected %s first, got %+v", a, results)
+	}
+	weight = 0.1
+	results, _ = c.SearchHybrid(q, 1)
+	if results[0].VecID != b {
+		t.Errorf("sparse weighted: expected %s first, got %+v", b, results)
+	}
+	// an explicit zero weight is sparse only, not the default
+	weight = 0
+	results, _ = c.SearchHybrid(q, 3)
+	if len(results) == 0 || results[0].VecID != b || results[0].Score != 1 {
+		t.Errorf("zero dense weight: expected %s first with the full sparse score, got %+v", b, results)
+	}
+
+	// one sided queries rank by that side alone
+	results, _ = c.SearchHybrid(HybridQuery{SparseIndices: []uint32{2}, SparseValues: []float32{1}}, 5)
+	if len(results) != 1 || results[0].VecID != b {
+		t.Errorf("sparse only: expected just %s, got %+v", b, results)
+	}
+	if _, err := c.SearchHybrid(HybridQuery{}, 5); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("expected ErrEmptyQuery, got %v", err)
+	}
+	weight = 2
+	if _, err := c.SearchHybrid(HybridQuery{Dense: []float32{1, 0}, DenseWeight: &weight, Fusion: FusionWeighted}, 5); err == nil {
+		t.Error("expected error for dense weight above 1")
+	}
+}
+
+func TestCollection_Hybrid_DeleteAndRecovery(t *testing.T) {
+	rootDir := t.TempDir()
+	c, ids := setupHybridCollection(t, rootDir)
+	// dense only point in a hybrid collection
+	plain, err := c.Insert([]float32{0, 1}, nil)
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := c.InsertSparse([]uint32{1}, []float32{1}, nil); !errors.Is(err, ErrVectorKindMismatch) {
+		t.Errorf("expected ErrVectorKindMismatch for sparse only insert, got %v", err)
+	}
+	c.Delete(ids[1])
+	c.Close()
+
+	reopened, err := OpenCollection(rootDir, "hybrid", wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	defer reopened.Close()
+	results, err := reopened.SearchSparse([]uint32{1}, []float32{1}, 5)
+	if err != nil {
+		t.Fatalf("SearchSparse failed: %v", err)
+	}
+	// deleted b is gone from the sparse side, the dense only point never had one
+	if len(results) != 2 || results[0].VecID != ids[0] || results[1].VecID != ids[2] {
+		t.Errorf("unexpected sparse results after replay %+v", results)
+	}
+	dense, _ := reopened.Search([]float32{0, 1}, 5)
+	if len(dense) != 3 || dense[0].VecID != plain {
+		t.Errorf("unexpected dense results after replay %+v", dense)
+	}
+}
@@ -0,0 +1,120 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// Maximal marginal relevance diversifies a search: out of an over-fetched candidate set it
+// greedily picks the point maximizing
+//
+//	lambda * sim(query, point) - (1 - lambda) * max sim(point, picked)
+//
+// so near duplicates of already picked points lose against slightly less relevant but
+// different ones. lambda 1 is a plain search, lambda 0 only looks for diversity.
+
+// default lambda, relevance and diversity weigh the same
+const DefaultMMRLambda = 0.5
+
+// MMRQuery is a diversified search over one dense field
+type MMRQuery struct {
+	Vector []float32
+	// relevance weight in [0, 1]
+	Lambda float64
+	// candidates fetched before picking, 0 means filterOversample * k
+	Candidates int
+	// dense field searched, DefaultVectorField is the top level vector
+	Field string
+}
+
+// SearchMMR returns k diverse points matching filter in pick order, scored by their similarity
+// to the query
+func (c *Collection) SearchMMR(q MMRQuery, k int, filter *Filter) ([]Result, error) {
+	if k <= 0 {
+		return []Result{}, errors.New("invalid input for number of results")
+	}
+	if q.Lambda < 0 || q.Lambda > 1 || math.IsNaN(q.Lambda) {
+		return []Result{}, fmt.Errorf("mmr lambda must be in [0, 1], got %v", q.Lambda)
+	}
+	if q.Candidates < 0 {
+		return []Result{}, errors.New("invalid input for number of mmr candidates")
+	}
+	if err := filter.validate(); err != nil {
+		return []Result{}, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	field, err := c.denseField(q.Field)
+	if err != nil {
+		return []Result{}, err
+	}
+	impl, ok := vector.LookupMetric(field.metric)
+	if !ok {
+		return []Result{}, ErrInvalidMetric
+	}
+	query, err := field.newQuery(q.Vector)
+	if err != nil {
+		return []Result{}, err
+	}
+	fetch := q.Candidates
+	if fetch == 0 {
+		fetch = filterOversample * k
+	}
+	fetch = max(fetch, k)
+	candidates, err := c.searchFiltered(field.idx, query, fetch, filter, nil)
+	if err != nil {
+		return []Result{}, err
+	}
+	return c.toResults(pickMMR(impl, query.Values(), field.idx, candidates, k, q.Lambda))
+}
+
+// greedy MMR selection of k candidates
+func pickMMR(impl vector.Metric, query []float32, idx index.VectorIndex, candidates []index.SearchResult, k int, lambda float64) []index.SearchResult {
+	type candidate struct {
+		id        int
+		vals      []float32
+		relevance float64
+		// highest similarity to any picked point
+		redundancy float64
+	}
+	pool := make([]*candidate, 0, len(candidates))
+	for _, res := range candidates {
+		vec, ok := idx.Get(res.VecId)
+		if !ok {
+			continue
+		}
+		vals := vec.Values()
+		pool = append(pool, &candidate{
+			id:         res.VecId,
+			vals:       vals,
+			relevance:  float64(impl.Score(query, vals)),
+			redundancy: math.Inf(-1),
+		})
+	}
+	picked := make([]index.SearchResult, 0, min(k, len(pool)))
+	for len(picked) < k && len(pool) > 0 {
+		best, bestScore := 0, math.Inf(-1)
+		for i, cand := range pool {
+			// nothing picked yet, relevance alone decides
+			score := cand.relevance
+			if len(picked) > 0 {
+				score = lambda*cand.relevance - (1-lambda)*cand.redundancy
+			}
+			// candidates arrive by relevance so the first of equal scores is the more relevant
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		chosen := pool[best]
+		picked = append(picked, index.SearchResult{VecId: chosen.id, Score: chosen.relevance})
+		pool = append(pool[:best], pool[best+1:]...)
+		for _, cand := range pool {
+			cand.redundancy = max(cand.redundancy, float64(impl.Score(chosen.vals, cand.vals)))
+		}
+	}
+	return picked
+}
@@ -0,0 +1,65 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollection_SearchMMR(t *testing.T) {
+	c := scrollCollection(t)
+	insert := func(vals []float32) string {
+		id, err := c.Insert(vals, nil)
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		return id
+	}
+	x1 := insert([]float32{1, 0.1})
+	x2 := insert([]float32{1, 0.1}) // near duplicate chunk
+	y := insert([]float32{1, -0.3})
+	z := insert([]float32{0, 1})
+	query := []float32{1, 0}
+
+	plain, _ := c.Search(query, 2)
+	if got := resultIDs(plain); !reflect.DeepEqual(got, []string{x1, x2}) {
+		t.Fatalf("plain search: expected x1, x2, got %v", got)
+	}
+
+	cases := []struct {
+		name   string
+		lambda float64
+		want   []string
+	}{
+		{"relevance only", 1, []string{x1, x2}},
+		{"balanced", DefaultMMRLambda, []string{x1, y}},
+		{"diversity only", 0, []string{x1, z}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := c.SearchMMR(MMRQuery{Vector: query, Lambda: tc.lambda}, 2, nil)
+			if err != nil {
+				t.Fatalf("SearchMMR failed: %v", err)
+			}
+			if got := resultIDs(results); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	// scores stay query similarities
+	results, _ := c.SearchMMR(MMRQuery{Vector: query, Lambda: DefaultMMRLambda}, 2, nil)
+	if results[0].Score != plain[0].Score {
+		t.Errorf("expected the query similarity %v, got %v", plain[0].Score, results[0].Score)
+	}
+	// a candidate pool of the duplicates only has nothing else to pick
+	results, _ = c.SearchMMR(MMRQuery{Vector: query, Lambda: DefaultMMRLambda, Candidates: 2}, 2, nil)
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{x1, x2}) {
+		t.Errorf("two candidates: expected x1, x2, got %v", got)
+	}
+
+	for _, lambda := range []float64{-0.1, 1.5} {
+		if _, err := c.SearchMMR(MMRQuery{Vector: query, Lambda: lambda}, 2, nil); err == nil {
+			t.Errorf("expected an error for lambda %v", lambda)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// Multi vector fields (VectorField.MultiVector) hold a variable number of token vectors per
+// point, e.g. ColBERT token embeddings, and are searched by MaxSim: every query token is
+// matched with its best document token and the matches are summed. Candidates come from a
+// token level search of the field's index and are reranked exactly.
+
+// a multi vector field resolved by name, the shape its token vectors are validated against
+type multiField struct {
+	idx       index.MultiVectorIndex
+	dimension int
+	metric    types.SimilarityMetric
+	precision types.Precision
+}
+
+// resolves a multi vector field, dense fields are a kind mismatch
+func (c *Collection) multiField(name string) (multiField, error) {
+	for _, vf := range c.config.Vectors {
+		if vf.Name != name {
+			continue
+		}
+		if !vf.MultiVector {
+			return multiField{}, fmt.Errorf("%w: %s is not a multi vector field", ErrVectorKindMismatch, name)
+		}
+		return multiField{c.multi[name], vf.Dimension, vf.Metric, vf.Precision}, nil
+	}
+	return multiField{}, fmt.Errorf("%w: %s", ErrUnknownVectorField, name)
+}
+
+// stored multi vector for the field, every row rounded to its precision
+func (f multiField) newMultiVector(rows [][]float32) (*vector.MultiVector, error) {
+	return f.build(rows, f.precision)
+}
+
+// query tokens are never rounded, only stored ones are
+func (f multiField) newQuery(rows [][]float32) (*vector.MultiVector, error) {
+	return f.build(rows, types.Float32Precision)
+}
+
+func (f multiField) build(rows [][]float32, prec types.Precision) (*vector.MultiVector, error) {
+	if len(rows) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	for _, row := range rows {
+		if len(row) != f.dimension {
+			return nil, ErrInvalidDimension
+		}
+	}
+	return vector.NewMultiVector(rows, f.dimension, f.metric, prec)
+}
+
+// rows the WAL logs for a multi vector of this field, raw input for float32 fields and the
+// constructed rows for half precision ones, like denseField.walValues
+func (f multiField) walRows(raw [][]float32, mv *vector.MultiVector) [][]float32 {
+	if f.precision == types.Float32Precision {
+		return raw
+	}
+	return mv.Rows()
+}
+
+// InsertWithMultiVectors adds a point with its default vector, vectors for any of the named dense
+// fields and token vectors for any of the multi vector fields
+func (c *Collection) InsertWithMultiVectors(vecVals []float32, named map[string][]float32, multi map[string][][]float32, payload any) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.insertPoint(pointInput{dense: vecVals, named: named, multi: multi}, payload)
+}
+
+// SearchMaxSim returns the k points of a multi vector field with the highest MaxSim score
+// against the query tokens, points without a multi vector for the field are never returned
+func (c *Collection) SearchMaxSim(field string, query [][]float32, k int) ([]Result, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	f, err := c.multiField(field)
+	if err != nil {
+		return []Result{}, err
+	}
+	if k <= 0 {
+		return []Result{}, errors.New("invalid input for number of results")
+	}
+	queryVector, err := f.newQuery(query)
+	if err != nil {
+		return []Result{}, err
+	}
+	expired := c.expiredIDs()
+	idxResult, err := f.idx.Search(queryVector, k+len(expired))
+	if err != nil {
+		return []Result{}, err
+	}
+	return c.toResults(dropExpired(idxResult, expired, k))
+}
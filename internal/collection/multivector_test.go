@@ -0,0 +1,104 @@
+package collection
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func multiVectorConfig() CollectionConfig {
+	cfg := namedFieldsConfig()
+	cfg.Vectors = append(cfg.Vectors,
+		VectorField{Name: "tokens", Dimension: 2, Metric: types.InnerProduct, IndexType: types.LinearIndex, MultiVector: true},
+		VectorField{Name: "half", Dimension: 2, Metric: types.Cosine, IndexType: types.LinearIndex, Precision: types.BFloat16Precision, MultiVector: true},
+	)
+	return cfg
+}
+
+func TestCollection_MultiVector_InsertAndSearch(t *testing.T) {
+	c, err := CreateCollection(multiVectorConfig(), t.TempDir(), wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+
+	// token bags of different lengths
+	a, err := c.InsertWithMultiVectors([]float32{1, 0}, nil, map[string][][]float32{
+		"tokens": {{1, 0}, {0, 1}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("InsertWithMultiVectors failed: %v", err)
+	}
+	b, err := c.InsertWithMultiVectors([]float32{0, 1}, map[string][]float32{"body": {1, 0, 0}}, map[string][][]float32{
+		"tokens": {{3, 0}},
+		"half":   {{1, 1}, {1, 0}, {0, 1}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("InsertWithMultiVectors failed: %v", err)
+	}
+
+	// MaxSim: b = 3+0, a = 1+1
+	results, err := c.SearchMaxSim("tokens", [][]float32{{1, 0}, {0, 1}}, 5)
+	if err != nil {
+		t.Fatalf("SearchMaxSim failed: %v", err)
+	}
+	if len(results) != 2 || results[0].VecID != b || results[0].Score != 3 || results[1].VecID != a || results[1].Score != 2 {
+		t.Errorf("unexpected tokens results %+v", results)
+	}
+	results, _ = c.SearchMaxSim("half", [][]float32{{0, 1}}, 5)
+	if len(results) != 1 || results[0].VecID != b {
+		t.Errorf("half: expected only %s, got %+v", b, results)
+	}
+
+	if _, err := c.SearchField("tokens", []float32{1, 0}, 1); !errors.Is(err, ErrVectorKindMismatch) {
+		t.Errorf("expected ErrVectorKindMismatch searching a multi field densely, got %v", err)
+	}
+	if _, err := c.SearchMaxSim("body", [][]float32{{1, 0, 0}}, 1); !errors.Is(err, ErrVectorKindMismatch) {
+		t.Errorf("expected ErrVectorKindMismatch for a dense field, got %v", err)
+	}
+	if _, err := c.SearchMaxSim("tokens", nil, 1); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("expected ErrEmptyQuery, got %v", err)
+	}
+	if _, err := c.InsertWithMultiVectors([]float32{1, 0}, nil, map[string][][]float32{"tokens": {{1, 0}, {1}}}, nil); !errors.Is(err, ErrInvalidDimension) {
+		t.Errorf("expected ErrInvalidDimension for a short token, got %v", err)
+	}
+	if _, err := c.InsertWithVectors([]float32{1, 0}, map[string][]float32{"tokens": {1, 0}}, nil); !errors.Is(err, ErrVectorKindMismatch) {
+		t.Errorf("expected ErrVectorKindMismatch inserting a single vector into a multi field, got %v", err)
+	}
+	if c.IDCounter() != 2 {
+		t.Errorf("expected id counter 2, got %d", c.IDCounter())
+	}
+}
+
+func TestCollection_MultiVector_DeleteAndRecovery(t *testing.T) {
+	rootDir := t.TempDir()
+	c, err := CreateCollection(multiVectorConfig(), rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	keep, _ := c.InsertWithMultiVectors([]float32{1, 0}, nil, map[string][][]float32{
+		"tokens": {{1, 0}, {0, 2}},
+		"half":   {{0.3, 0.7}},
+	}, nil)
+	gone, _ := c.InsertWithMultiVectors([]float32{0, 1}, nil, map[string][][]float32{"tokens": {{5, 5}}}, nil)
+	c.Delete(gone)
+	c.Close()
+
+	reopened, err := OpenCollection(rootDir, "docs", wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	defer reopened.Close()
+	results, err := reopened.SearchMaxSim("tokens", [][]float32{{1, 0}, {0, 1}}, 5)
+	if err != nil {
+		t.Fatalf("SearchMaxSim failed: %v", err)
+	}
+	if len(results) != 1 || results[0].VecID != keep || results[0].Score != 3 {
+		t.Errorf("unexpected tokens results after replay %+v", results)
+	}
+	if results, _ := reopened.SearchMaxSim("half", [][]float32{{0.3, 0.7}}, 5); len(results) != 1 || results[0].VecID != keep {
+		t.Errorf("unexpected half results after replay %+v", results)
+	}
+}
@@ -0,0 +1,115 @@
+package collection
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/payload"
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func onDiskPayloadConfig() CollectionConfig {
+	return CollectionConfig{
+		Name:           "ondisk",
+		Dimension:      2,
+		Metric:         types.Cosine,
+		IndexType:      types.LinearIndex,
+		DataType:       types.Text,
+		ModelName:      "model",
+		PayloadStorage: types.OnDiskPayloads,
+	}
+}
+
+func TestCreateCollection_InvalidPayloadStorage(t *testing.T) {
+	cfg := onDiskPayloadConfig()
+	cfg.PayloadStorage = 7
+	if _, err := CreateCollection(cfg, t.TempDir(), wal.SyncAlways); !errors.Is(err, ErrInvalidPayloadStorage) {
+		t.Errorf("expected ErrInvalidPayloadStorage, got %v", err)
+	}
+}
+
+func TestCollection_OnDiskPayloads(t *testing.T) {
+	rootDir := t.TempDir()
+	c, err := CreateCollection(onDiskPayloadConfig(), rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	keep, _ := c.Insert([]float32{1, 0}, map[string]string{"title": "kept"})
+	gone, _ := c.Insert([]float32{0, 1}, map[string]string{"title": "deleted"})
+	// on disk payloads are decoded from JSON on every Get
+	got, ok := c.Get(keep)
+	if m, _ := got.(map[string]any); !ok || m["title"] != "kept" {
+		t.Errorf("unexpected payload %v, %v", got, ok)
+	}
+	if err := c.Delete(gone); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := c.Get(gone); ok {
+		t.Error("deleted payload still readable")
+	}
+	c.Close()
+
+	storePath := filepath.Join(rootDir, "ondisk", payloadFileName)
+	if _, err := os.Stat(storePath); err != nil {
+		t.Fatalf("expected payload file at %s: %v", storePath, err)
+	}
+	// an entry the WAL never logged, as left behind by a torn WAL tail
+	fs, err := payload.OpenFileStore(storePath, nil, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenFileStore failed: %v", err)
+	}
+	fs.Put("orphan", nil, []byte(`"x"`))
+	fs.Close()
+
+	reopened, err := OpenCollection(rootDir, "ondisk", wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	defer reopened.Close()
+	got, ok = reopened.Get(keep)
+	if m, _ := got.(map[string]any); !ok || m["title"] != "kept" {
+		t.Errorf("unexpected payload after reopen %v, %v", got, ok)
+	}
+	if reopened.payloads.Len() != 1 || reopened.payloads.Has("orphan") {
+		t.Errorf("expected only the kept payload after replay, got %v", reopened.payloads.Keys())
+	}
+}
+
+// Post-condition: reopening replays inserts and updates without appending to the payload file.
+func TestCollection_OnDiskPayloads_ReopenDoesNotGrowStore(t *testing.T) {
+	rootDir := t.TempDir()
+	c, err := CreateCollection(onDiskPayloadConfig(), rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	id, _ := c.Insert([]float32{1, 0}, map[string]any{"title": "a", "n": 1})
+	c.Insert([]float32{0, 1}, map[string]any{"title": "b"})
+	if err := c.SetPayload(id, map[string]any{"n": 2}); err != nil {
+		t.Fatalf("SetPayload failed: %v", err)
+	}
+	if err := c.SetPayload(id, map[string]any{"n": 3}); err != nil {
+		t.Fatalf("SetPayload failed: %v", err)
+	}
+	c.Close()
+
+	storePath := filepath.Join(rootDir, "ondisk", payloadFileName)
+	before, _ := os.Stat(storePath)
+	for range 2 {
+		reopened, err := OpenCollection(rootDir, "ondisk", wal.SyncAlways)
+		if err != nil {
+			t.Fatalf("OpenCollection failed: %v", err)
+		}
+		got, _ := reopened.Get(id)
+		if m, _ := got.(map[string]any); m["n"] != float64(3) {
+			t.Errorf("expected the last update after replay, got %v", got)
+		}
+		reopened.Close()
+	}
+	after, _ := os.Stat(storePath)
+	if after.Size() != before.Size() {
+		t.Errorf("payload file grew on reopen, %d -> %d bytes", before.Size(), after.Size())
+	}
+}
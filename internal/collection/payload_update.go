@@ -0,0 +1,188 @@
+package collection
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+)
+
+// Payload updates change the payload of an existing point without touching its vectors. The WAL
+// logs only the delta (an OpUpdate record), replay applies it to the payload as of that point.
+// Every kind is last writer wins per key, so replaying all updates over a newer stored payload
+// (persistent stores keep theirs across opens) still ends in the same payload.
+
+// SetPayload sets the keys of patch on the payload of a point, other keys are kept.
+// The payload must be a JSON object or empty
+func (c *Collection) SetPayload(id string, patch map[string]any) error {
+	if len(patch) == 0 {
+		return nil
+	}
+	delta, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	return c.updatePayload(id, wal.UpdateSetKeys, delta)
+}
+
+// DeletePayloadKeys removes keys from the payload of a point, missing keys are ignored
+func (c *Collection) DeletePayloadKeys(id string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	delta, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return c.updatePayload(id, wal.UpdateDeleteKeys, delta)
+}
+
+// OverwritePayload replaces the whole payload of a point
+func (c *Collection) OverwritePayload(id string, payload any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	internalID, ok := c.extToInt[id]
+	if !ok || c.expired(internalID) {
+		return ErrNotFound
+	}
+	// validated like an insert payload
+	payload, raw, err := c.encodePayload(payload)
+	if err != nil {
+		return err
+	}
+	prior, err := c.priorPayload(id)
+	if err != nil {
+		return err
+	}
+	lsn, err := c.wal.AppendUpdatePayload(id, uint64(internalID), wal.UpdateOverwrite, raw)
+	if err != nil {
+		return err
+	}
+	c.saveUndo(internalID, lsn, prior, nil)
+	return c.payloads.Put(id, payload, raw)
+}
+
+// validates the updated payload, logs the delta and stores the result
+func (c *Collection) updatePayload(id string, kind uint8, delta []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	internalID, ok := c.extToInt[id]
+	if !ok || c.expired(internalID) {
+		return ErrNotFound
+	}
+	// 1. Validation phase, the WAL only sees updates whose result is a valid payload
+	payload, raw, err := c.applyPayloadUpdate(id, kind, delta, false)
+	if err != nil {
+		return err
+	}
+	prior, err := c.priorPayload(id)
+	if err != nil {
+		return err
+	}
+	// 2. Write to WAL
+	lsn, err := c.wal.AppendUpdatePayload(id, uint64(internalID), kind, delta)
+	if err != nil {
+		return err
+	}
+	// 3. Memory Mutation
+	c.saveUndo(internalID, lsn, prior, nil)
+	return c.payloads.Put(id, payload, raw)
+}
+
+// payload of id before an update, only read while snapshots are open. Caller holds c.mu
+func (c *Collection) priorPayload(id string) (any, error) {
+	if len(c.snapshots) == 0 {
+		return nil, nil
+	}
+	payload, _, err := c.payloads.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload of %s: %w", id, err)
+	}
+	return payload, nil
+}
+
+// computes the payload of id after a logged update, returns it in stored form and as JSON.
+// replaying tolerates a stored payload that is not an object: set and delete are only logged
+// for objects, so it was written by a later overwrite that replay has yet to reach.
+// Caller holds c.mu
+func (c *Collection) applyPayloadUpdate(id string, kind uint8, delta []byte, replaying bool) (any, []byte, error) {
+	var raw []byte
+	switch kind {
+	case wal.UpdateOverwrite:
+		raw = delta
+	case wal.UpdateSetKeys, wal.UpdateDeleteKeys:
+		current, _, err := c.payloads.Get(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		obj, err := payloadObject(current)
+		if errors.Is(err, ErrPayloadNotObject) && replaying {
+			obj, err = map[string]any{}, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if kind == wal.UpdateSetKeys {
+			var patch map[string]any
+			if err := decodeExact(delta, &patch); err != nil {
+				return nil, nil, fmt.Errorf("corrupt payload patch: %w", err)
+			}
+			for k, v := range patch {
+				obj[k] = v
+			}
+		} else {
+			var keys []string
+			if err := json.Unmarshal(delta, &keys); err != nil {
+				return nil, nil, fmt.Errorf("corrupt payload key list: %w", err)
+			}
+			for _, k := range keys {
+				delete(obj, k)
+			}
+		}
+		if raw, err = json.Marshal(obj); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("invalid payload update kind %d", kind)
+	}
+	payload, err := c.decodePayload(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.config.PayloadSchema != nil {
+		// canonical JSON of the typed payload, like inserts log it
+		if raw, err = json.Marshal(payload); err != nil {
+			return nil, nil, err
+		}
+	}
+	return payload, raw, nil
+}
+
+// the payload as a JSON object with exact numbers, empty payloads are an empty object
+func payloadObject(payload any) (map[string]any, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := decodeExact(raw, &value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return map[string]any{}, nil
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w, got %T", ErrPayloadNotObject, value)
+	}
+	return obj, nil
+}
+
+// decodes JSON keeping numbers as json.Number so int64 values survive the round trip
+func decodeExact(raw []byte, dst any) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(dst)
+}
@@ -0,0 +1,104 @@
+package collection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func TestCollection_PayloadUpdates(t *testing.T) {
+	for _, storage := range []types.PayloadStorage{types.InMemoryPayloads, types.OnDiskPayloads} {
+		rootDir := t.TempDir()
+		cfg := onDiskPayloadConfig()
+		cfg.PayloadStorage = storage
+		c, err := CreateCollection(cfg, rootDir, wal.SyncAlways)
+		if err != nil {
+			t.Fatalf("CreateCollection failed: %v", err)
+		}
+		id, _ := c.Insert([]float32{1, 0}, map[string]any{"title": "a", "status": "draft", "n": 1})
+		other, _ := c.Insert([]float32{0, 1}, nil)
+
+		if err := c.SetPayload(id, map[string]any{"status": "published", "views": 10}); err != nil {
+			t.Fatalf("SetPayload failed: %v", err)
+		}
+		if err := c.DeletePayloadKeys(id, []string{"n", "missing"}); err != nil {
+			t.Fatalf("DeletePayloadKeys failed: %v", err)
+		}
+		// empty payloads patch like an empty object
+		if err := c.SetPayload(other, map[string]any{"k": "v"}); err != nil {
+			t.Fatalf("SetPayload on empty payload failed: %v", err)
+		}
+		want := map[string]any{"title": "a", "status": "published", "views": float64(10)}
+		if got, _ := c.Get(id); !reflect.DeepEqual(got, want) {
+			t.Errorf("storage %d: expected %v, got %v", storage, want, got)
+		}
+		c.Close()
+
+		reopened, err := OpenCollection(rootDir, cfg.Name, wal.SyncAlways)
+		if err != nil {
+			t.Fatalf("OpenCollection failed: %v", err)
+		}
+		if got, _ := reopened.Get(id); !reflect.DeepEqual(got, want) {
+			t.Errorf("storage %d after replay: expected %v, got %v", storage, want, got)
+		}
+		if got, _ := reopened.Get(other); !reflect.DeepEqual(got, map[string]any{"k": "v"}) {
+			t.Errorf("storage %d after replay: unexpected payload %v", storage, got)
+		}
+		if err := reopened.OverwritePayload(id, "plain"); err != nil {
+			t.Fatalf("OverwritePayload failed: %v", err)
+		}
+		if err := reopened.SetPayload(id, map[string]any{"x": 1}); !errors.Is(err, ErrPayloadNotObject) {
+			t.Errorf("expected ErrPayloadNotObject, got %v", err)
+		}
+		if err := reopened.SetPayload("nope", map[string]any{"x": 1}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		reopened.Close()
+
+		// a second replay runs every update again over the already persisted payloads
+		reopened, err = OpenCollection(rootDir, cfg.Name, wal.SyncAlways)
+		if err != nil {
+			t.Fatalf("OpenCollection failed: %v", err)
+		}
+		if got, _ := reopened.Get(id); got != "plain" {
+			t.Errorf("storage %d: expected overwritten payload after replay, got %v", storage, got)
+		}
+		reopened.Close()
+	}
+}
+
+func TestCollection_PayloadUpdates_Schema(t *testing.T) {
+	rootDir := t.TempDir()
+	c, err := CreateCollection(schemaConfig(types.OnDiskPayloads), rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	id, _ := c.Insert([]float32{1, 0}, map[string]any{"title": "t", "views": 1})
+	if err := c.DeletePayloadKeys(id, []string{"title"}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("expected ErrInvalidPayload removing a required field, got %v", err)
+	}
+	if err := c.SetPayload(id, map[string]any{"views": "many"}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("expected ErrInvalidPayload for a mistyped field, got %v", err)
+	}
+	if err := c.OverwritePayload(id, map[string]any{"views": 2}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("expected ErrInvalidPayload overwriting without required field, got %v", err)
+	}
+	const views = int64(1<<60 + 3)
+	if err := c.SetPayload(id, map[string]any{"views": views, "draft": true}); err != nil {
+		t.Fatalf("SetPayload failed: %v", err)
+	}
+	c.Close()
+
+	reopened, err := OpenCollection(rootDir, "typed", wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	defer reopened.Close()
+	want := map[string]any{"title": "t", "views": views, "draft": true}
+	if got, _ := reopened.Get(id); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
@@ -0,0 +1,203 @@
+package collection
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// Recommendations search by example points instead of a query vector: more like the positive
+// examples, less like the negative ones. The examples' stored vectors are read back from the
+// index and the examples themselves are never part of the results.
+
+// RecommendStrategy selects how examples become a ranking
+type RecommendStrategy int
+
+const (
+	// one query vector, avg(positive) + (avg(positive) - avg(negative)), zero value
+	RecommendAverage RecommendStrategy = iota
+	// every candidate is scored against each example, the best positive score wins if it beats
+	// the best negative one. Candidates where the negative wins rank below all of those, among
+	// themselves by -(best negative score), the scores of the two tiers are not comparable
+	RecommendBestScore
+)
+
+// RecommendQuery is a recommendation over one dense field
+type RecommendQuery struct {
+	Positive []string
+	Negative []string
+	Strategy RecommendStrategy
+	// dense field the examples are compared on, DefaultVectorField is the top level vector
+	Field string
+}
+
+// Recommend returns the k points most like the positive and least like the negative examples
+// with the average strategy, restricted to points matching filter
+func (c *Collection) Recommend(positiveIDs, negativeIDs []string, k int, filter *Filter) ([]Result, error) {
+	return c.RecommendWith(RecommendQuery{Positive: positiveIDs, Negative: negativeIDs}, k, filter)
+}
+
+// RecommendWith runs a recommendation with an explicit strategy and field
+func (c *Collection) RecommendWith(q RecommendQuery, k int, filter *Filter) ([]Result, error) {
+	if k <= 0 {
+		return []Result{}, errors.New("invalid input for number of results")
+	}
+	if len(q.Positive) == 0 {
+		return []Result{}, fmt.Errorf("%w: recommend needs at least one positive example", ErrEmptyQuery)
+	}
+	if err := filter.validate(); err != nil {
+		return []Result{}, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	field, err := c.denseField(q.Field)
+	if err != nil {
+		return []Result{}, err
+	}
+	exclude := make(map[int]bool, len(q.Positive)+len(q.Negative))
+	positive, err := c.exampleVectors(field, q.Positive, exclude)
+	if err != nil {
+		return []Result{}, err
+	}
+	negative, err := c.exampleVectors(field, q.Negative, exclude)
+	if err != nil {
+		return []Result{}, err
+	}
+
+	var idxResult []index.SearchResult
+	switch q.Strategy {
+	case RecommendAverage:
+		idxResult, err = c.recommendAverage(field, positive, negative, k, filter, exclude)
+	case RecommendBestScore:
+		idxResult, err = c.recommendBestScore(field, positive, negative, k, filter, exclude)
+	default:
+		return []Result{}, fmt.Errorf("invalid recommend strategy %d", q.Strategy)
+	}
+	if err != nil {
+		return []Result{}, err
+	}
+	return c.toResults(idxResult)
+}
+
+// stored vectors of the examples on field, their internal ids are added to exclude.
+// Caller holds c.mu
+func (c *Collection) exampleVectors(field denseField, ids []string, exclude map[int]bool) ([][]float32, error) {
+	vecs := make([][]float32, 0, len(ids))
+	for _, id := range ids {
+		internalID, ok := c.extToInt[id]
+		if !ok || c.expired(internalID) {
+			return nil, fmt.Errorf("%w: example %s", ErrNotFound, id)
+		}
+		vec, ok := field.idx.Get(internalID)
+		if !ok {
+			return nil, fmt.Errorf("%w: example %s has no vector for the field", ErrNotFound, id)
+		}
+		exclude[internalID] = true
+		vecs = append(vecs, vec.Values())
+	}
+	return vecs, nil
+}
+
+func (c *Collection) recommendAverage(field denseField, positive, negative [][]float32, k int, filter *Filter, exclude map[int]bool) ([]index.SearchResult, error) {
+	avgPos := average(positive, field.dimension)
+	queryVals := avgPos
+	if len(negative) > 0 {
+		avgNeg := average(negative, field.dimension)
+		queryVals = make([]float32, field.dimension)
+		for i := range queryVals {
+			queryVals[i] = 2*avgPos[i] - avgNeg[i]
+		}
+	}
+	query, err := field.newQuery(queryVals)
+	if err != nil {
+		return nil, fmt.Errorf("recommend query: %w", err)
+	}
+	return c.searchFiltered(field.idx, query, k, filter, exclude)
+}
+
+// candidates are the nearest points of every positive example, rescored against all examples
+func (c *Collection) recommendBestScore(field denseField, positive, negative [][]float32, k int, filter *Filter, exclude map[int]bool) ([]index.SearchResult, error) {
+	impl, ok := vector.LookupMetric(field.metric)
+	if !ok {
+		return nil, ErrInvalidMetric
+	}
+	candidates := make(map[int]bool)
+	for _, vals := range positive {
+		query, err := field.newQuery(vals)
+		if err != nil {
+			return nil, fmt.Errorf("recommend query: %w", err)
+		}
+		results, err := c.searchFiltered(field.idx, query, filterOversample*k, filter, exclude)
+		if err != nil {
+			return nil, err
+		}
+		for _, res := range results {
+			candidates[res.VecId] = true
+		}
+	}
+	// higher scores are more similar for every metric, distances are negated, so the tier
+	// decides first and the score only orders candidates within it
+	type tiered struct {
+		res index.SearchResult
+		won bool
+	}
+	scored := make([]tiered, 0, len(candidates))
+	for id := range candidates {
+		vec, ok := field.idx.Get(id)
+		if !ok {
+			continue
+		}
+		stored := vec.Values()
+		bestPos := bestScore(impl, positive, stored)
+		cand := tiered{res: index.SearchResult{VecId: id, Score: float64(bestPos)}, won: true}
+		if len(negative) > 0 {
+			if bestNeg := bestScore(impl, negative, stored); bestNeg >= bestPos {
+				cand = tiered{res: index.SearchResult{VecId: id, Score: -float64(bestNeg)}}
+			}
+		}
+		scored = append(scored, cand)
+	}
+	slices.SortFunc(scored, func(a, b tiered) int {
+		if a.won != b.won {
+			if a.won {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(b.res.Score, a.res.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.res.VecId, b.res.VecId)
+	})
+	results := make([]index.SearchResult, 0, min(k, len(scored)))
+	for _, cand := range scored[:min(k, len(scored))] {
+		results = append(results, cand.res)
+	}
+	return results, nil
+}
+
+// best score of stored against any of the examples
+func bestScore(impl vector.Metric, examples [][]float32, stored []float32) float32 {
+	best := impl.Score(examples[0], stored)
+	for _, ex := range examples[1:] {
+		best = max(best, impl.Score(ex, stored))
+	}
+	return best
+}
+
+func average(vecs [][]float32, dim int) []float32 {
+	avg := make([]float32, dim)
+	for _, vals := range vecs {
+		for i, x := range vals {
+			avg[i] += x
+		}
+	}
+	for i := range avg {
+		avg[i] /= float32(len(vecs))
+	}
+	return avg
+}
@@ -0,0 +1,129 @@
+package collection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+// positive example p, negative example n and three candidates around them
+func recommendCollection(t *testing.T) (c *Collection, p, n, a, b, d string) {
+	t.Helper()
+	c = scrollCollection(t)
+	insert := func(vals []float32, payload any) string {
+		id, err := c.Insert(vals, payload)
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		return id
+	}
+	p = insert([]float32{1, 0}, map[string]any{"kind": "example"})
+	n = insert([]float32{0, 1}, map[string]any{"kind": "example"})
+	a = insert([]float32{1, 0.1}, map[string]any{"kind": "near"})
+	b = insert([]float32{0.1, 1}, map[string]any{"kind": "far"})
+	d = insert([]float32{1, -0.5}, map[string]any{"kind": "away"})
+	return c, p, n, a, b, d
+}
+
+func resultIDs(results []Result) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.VecID
+	}
+	return ids
+}
+
+func TestCollection_Recommend(t *testing.T) {
+	c, p, n, a, b, d := recommendCollection(t)
+
+	results, err := c.Recommend([]string{p}, nil, 10, nil)
+	if err != nil {
+		t.Fatalf("Recommend failed: %v", err)
+	}
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{a, d, b, n}) {
+		t.Errorf("positive only: expected a, d, b, n, got %v", got)
+	}
+
+	// the negative example pushes the query away from (0, 1), d overtakes a
+	results, err = c.Recommend([]string{p}, []string{n}, 10, nil)
+	if err != nil {
+		t.Fatalf("Recommend failed: %v", err)
+	}
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{d, a, b}) {
+		t.Errorf("with negative: expected d, a, b, got %v", got)
+	}
+
+	results, _ = c.Recommend([]string{p}, []string{n}, 1, nil)
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{d}) {
+		t.Errorf("k=1: expected d, got %v", got)
+	}
+
+	filter := &Filter{MustNot: []Condition{{Key: "kind", Match: "away"}}}
+	results, _ = c.Recommend([]string{p}, []string{n}, 1, filter)
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{a}) {
+		t.Errorf("filtered: expected a, got %v", got)
+	}
+}
+
+func TestCollection_Recommend_BestScore(t *testing.T) {
+	c, p, n, a, b, d := recommendCollection(t)
+	results, err := c.RecommendWith(RecommendQuery{Positive: []string{p}, Negative: []string{n}, Strategy: RecommendBestScore}, 10, nil)
+	if err != nil {
+		t.Fatalf("RecommendWith failed: %v", err)
+	}
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{a, d, b}) {
+		t.Fatalf("expected a, d, b, got %v", got)
+	}
+	// b is closer to the negative example so it ranks below zero
+	if results[1].Score <= 0 || results[2].Score >= 0 {
+		t.Errorf("expected positive scores for a and d and a negative one for b, got %+v", results)
+	}
+}
+
+// Post-condition: with a distance metric, where every score is at most zero, a candidate closer
+// to a negative example still ranks below all candidates closer to a positive one.
+func TestCollection_Recommend_BestScore_Euclidean(t *testing.T) {
+	c, err := CreateCollection(CollectionConfig{Name: "euclid", Dimension: 2, Metric: types.Euclidean, IndexType: types.LinearIndex, DataType: types.Text, ModelName: "t"}, t.TempDir(), wal.SyncOS)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	insert := func(vals []float32) string {
+		id, err := c.Insert(vals, nil)
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		return id
+	}
+	p := insert([]float32{10, 0})
+	n := insert([]float32{0, 10})
+	nearPos := insert([]float32{9.7, 0.1})
+	midway := insert([]float32{4, 5})
+	nearNeg := insert([]float32{0, 10.001})
+	results, err := c.RecommendWith(RecommendQuery{Positive: []string{p}, Negative: []string{n}, Strategy: RecommendBestScore}, 10, nil)
+	if err != nil {
+		t.Fatalf("RecommendWith failed: %v", err)
+	}
+	if got := resultIDs(results); !reflect.DeepEqual(got, []string{nearPos, midway, nearNeg}) {
+		t.Errorf("expected near positive, midway, near negative, got %v", got)
+	}
+}
+
+func TestCollection_Recommend_Errors(t *testing.T) {
+	c, p, _, _, _, _ := recommendCollection(t)
+	if _, err := c.Recommend(nil, []string{p}, 3, nil); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("expected ErrEmptyQuery without positives, got %v", err)
+	}
+	if _, err := c.Recommend([]string{"missing"}, nil, 3, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown example, got %v", err)
+	}
+	if _, err := c.Recommend([]string{p}, nil, 0, nil); err == nil {
+		t.Error("expected an error for k=0")
+	}
+	if _, err := c.RecommendWith(RecommendQuery{Positive: []string{p}, Field: "nope"}, 3, nil); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -1,9 +1,9 @@
 package collection
 
 import (
-	"encoding/json"
 	"fmt"
 
+	"github.com/Kasbe14/Dattaniddhi/internal/store/payload"
 	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
 	"github.com/Kasbe14/Dattaniddhi/internal/vector"
 )
@@ -18,20 +18,22 @@ func (c *Collection) LoadState() error {
 	//again reseting the colleciton defensive for multiple load state calls in row
 	c.extToInt = make(map[string]int)
 	c.intToExt = make(map[int]string)
-	c.payload = make(map[string]any)
+	// expiry times are replayed with the inserts, expired points come back hidden until reaped
+	c.expiry = nil
+	// snapshots pinned the state about to be rebuilt
+	c.releaseSnapshots()
+	// the payload store is kept, it may already hold what the WAL is about to replay
 	records, err := c.wal.Recover()
 	if err != nil {
 		return fmt.Errorf("wal failed to recover records: %w", err)
 	}
+	store := newReplayStore(c.payloads)
+	c.payloads = store
+	defer func() { c.payloads = store.PayloadStore }()
 	for _, record := range records {
 		switch record.OpType {
-		case wal.OpInsert:
-			vector, err := vector.NewVector(record.Vector, c.config.Dimension)
-			if err != nil {
-				return fmt.Errorf("failed to create vector while loading collection %s: %w", c.config.Name, err)
-			}
-
-			added, err := c.index.Add(int(record.IntID), vector)
+		case wal.OpInsert, wal.OpInsertSparse:
+			added, err := c.replayInsert(record)
 			if err != nil {
 				return fmt.Errorf("recovery failed: %w", err)
 			}
@@ -41,20 +43,36 @@ func (c *Collection) LoadState() error {
 
 			c.extToInt[record.ExtID] = int(record.IntID)
 			c.intToExt[int(record.IntID)] = record.ExtID
+			c.setExpiry(int(record.IntID), record.ExpiresAt)
 
 			if int(record.IntID) > c.idCounter {
 				c.idCounter = int(record.IntID)
 			}
 
-			var payloadData any
-			// Only attempt to unmarshal if there is actual JSON data
-			if len(record.MetaData) > 0 {
-				err = json.Unmarshal(record.MetaData, &payloadData)
-				if err != nil {
-					return fmt.Errorf("recovery failed: corrupt payload data on ID %s", record.ExtID)
-				}
+			// persistent stores keep payloads across opens, only missing ones are put back
+			if c.payloads.Has(record.ExtID) {
+				continue
+			}
+			payloadData, err := c.decodePayload(record.MetaData)
+			if err != nil {
+				return fmt.Errorf("recovery failed: corrupt payload data on ID %s", record.ExtID)
+			}
+			if err := store.PayloadStore.Put(record.ExtID, payloadData, record.MetaData); err != nil {
+				return fmt.Errorf("recovery failed: payload store: %w", err)
+			}
+
+		case wal.OpUpdate:
+			// updates are only logged for live points
+			if _, ok := c.extToInt[record.ExtID]; !ok {
+				return fmt.Errorf("recovery failed: payload update of unknown ID %s", record.ExtID)
+			}
+			payloadData, raw, err := c.applyPayloadUpdate(record.ExtID, record.UpdateKind, record.MetaData, true)
+			if err != nil {
+				return fmt.Errorf("recovery failed: payload update on ID %s: %w", record.ExtID, err)
+			}
+			if err := c.payloads.Put(record.ExtID, payloadData, raw); err != nil {
+				return fmt.Errorf("recovery failed: payload store: %w", err)
 			}
-			c.payload[record.ExtID] = payloadData
 
 		case wal.OpDelete:
 			internalID := record.IntID
@@ -64,15 +82,139 @@ func (c *Collection) LoadState() error {
 				c.idCounter = int(record.IntID)
 			}
 
-			err := c.index.Delete(int(internalID))
+			err := c.deleteFromIndex(int(internalID))
 			if err != nil {
 				return fmt.Errorf("FATAL: Index failed to delete %s, WAL out of sync: %v", extID, err)
 			}
 
 			delete(c.extToInt, extID)
 			delete(c.intToExt, int(internalID))
-			delete(c.payload, extID)
+			delete(c.expiry, int(internalID))
+			if err := c.payloads.Delete(extID); err != nil {
+				return fmt.Errorf("recovery failed: payload store: %w", err)
+			}
+		}
+	}
+	if err := store.flush(); err != nil {
+		return fmt.Errorf("recovery failed: payload store: %w", err)
+	}
+	// payloads of points the WAL does not hold, e.g. a store write that outlived a torn WAL tail
+	for _, extID := range c.payloads.Keys() {
+		if _, ok := c.extToInt[extID]; ok {
+			continue
+		}
+		if err := c.payloads.Delete(extID); err != nil {
+			return fmt.Errorf("recovery failed: payload store: %w", err)
+		}
+	}
+	// the replayed points decide whether anything can expire
+	c.ensureReaper()
+	return nil
+}
+
+// adds every vector of an insert record to the collection's indexes, the record kind must match them
+func (c *Collection) replayInsert(record wal.DecodedRecords) (bool, error) {
+	if record.OpType == wal.OpInsertSparse {
+		if c.sparse == nil {
+			return false, fmt.Errorf("sparse insert record in dense collection %s", c.config.Name)
+		}
+		sparseVec, err := c.newSparseVector(record.SparseIndices, record.SparseValues)
+		if err != nil {
+			return false, fmt.Errorf("failed to create sparse vector while loading collection %s: %w", c.config.Name, err)
+		}
+		return c.sparse.Add(int(record.IntID), sparseVec)
+	}
+	field, err := c.denseField(DefaultVectorField)
+	if err != nil {
+		return false, fmt.Errorf("dense insert record in sparse collection %s", c.config.Name)
+	}
+	vec, err := field.newVector(record.Vector)
+	if err != nil {
+		return false, fmt.Errorf("failed to create vector while loading collection %s: %w", c.config.Name, err)
+	}
+	adds := []pendingAdd{{idx: c.index, vec: vec}}
+	for _, nv := range record.NamedVectors {
+		field, err := c.denseField(nv.Name)
+		if err != nil {
+			return false, fmt.Errorf("insert record for collection %s: %w", c.config.Name, err)
+		}
+		namedVec, err := field.newVector(nv.Vector)
+		if err != nil {
+			return false, fmt.Errorf("failed to create vector for field %s while loading collection %s: %w", nv.Name, c.config.Name, err)
+		}
+		adds = append(adds, pendingAdd{idx: field.idx, vec: namedVec})
+	}
+	for _, mv := range record.MultiVectors {
+		field, err := c.multiField(mv.Name)
+		if err != nil {
+			return false, fmt.Errorf("insert record for collection %s: %w", c.config.Name, err)
+		}
+		multiVec, err := field.newMultiVector(mv.Vectors)
+		if err != nil {
+			return false, fmt.Errorf("failed to create multi vector for field %s while loading collection %s: %w", mv.Name, c.config.Name, err)
+		}
+		adds = append(adds, pendingAdd{multiIdx: field.idx, multiVec: multiVec})
+	}
+	var sparseVec *vector.SparseVector
+	// sparse half of a hybrid point
+	if len(record.SparseIndices) > 0 {
+		if c.sparse == nil {
+			return false, fmt.Errorf("hybrid insert record in collection %s without sparse config", c.config.Name)
+		}
+		sparseVec, err = c.newSparseVector(record.SparseIndices, record.SparseValues)
+		if err != nil {
+			return false, fmt.Errorf("failed to create sparse vector while loading collection %s: %w", c.config.Name, err)
+		}
+	}
+	return c.addToIndexes(int(record.IntID), adds, sparseVec)
+}
+
+// replayStore buffers the payloads replayed updates produce on top of the collection's store.
+// The store is written once per updated point with its final payload, so a persistent store
+// does not get every intermediate payload appended again on each open
+type replayStore struct {
+	payload.PayloadStore
+	pending map[string]replayedPayload
+}
+
+type replayedPayload struct {
+	payload any
+	raw     []byte
+}
+
+func newReplayStore(store payload.PayloadStore) *replayStore {
+	return &replayStore{PayloadStore: store, pending: make(map[string]replayedPayload)}
+}
+
+func (rs *replayStore) Put(id string, payload any, raw []byte) error {
+	rs.pending[id] = replayedPayload{payload, raw}
+	return nil
+}
+
+func (rs *replayStore) Get(id string) (any, bool, error) {
+	if p, ok := rs.pending[id]; ok {
+		return p.payload, true, nil
+	}
+	return rs.PayloadStore.Get(id)
+}
+
+func (rs *replayStore) Has(id string) bool {
+	_, ok := rs.pending[id]
+	return ok || rs.PayloadStore.Has(id)
+}
+
+func (rs *replayStore) Delete(id string) error {
+	delete(rs.pending, id)
+	return rs.PayloadStore.Delete(id)
+}
+
+// writes the buffered payloads through, stores skip the ones they already hold unchanged
+func (rs *replayStore) flush() error {
+	for id, p := range rs.pending {
+		if err := rs.PayloadStore.Put(id, p.payload, p.raw); err != nil {
+			return err
 		}
 	}
+	clear(rs.pending)
 	return nil
 }
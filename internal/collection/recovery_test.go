@@ -2,8 +2,10 @@ package collection
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
 	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
 	"github.com/Kasbe14/Dattaniddhi/internal/types"
 	"github.com/Kasbe14/Dattaniddhi/internal/vector"
@@ -173,3 +175,151 @@ func TestCollection_LoadState_CompensationReplay(t *testing.T) {
 		t.Error("Compensation failed: Vector remains in extToInt map")
 	}
 }
+
+// -----------------------------------------------------------------------------
+// Quantized collections: mode persists in config.json and replay re-quantizes
+// -----------------------------------------------------------------------------
+func TestCollection_ScalarQuantization_SurvivesReplay(t *testing.T) {
+	rootDir := t.TempDir()
+	cfg := CollectionConfig{
+		Name:         "quantized",
+		Dimension:    2,
+		Metric:       types.Cosine,
+		IndexType:    types.LinearIndex,
+		DataType:     types.Text,
+		ModelName:    "model",
+		Quantization: index.QuantizationConfig{Type: types.ScalarQuantization, Rescore: true},
+	}
+	c1, err := CreateCollection(cfg, rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	idExact, _ := c1.Insert([]float32{1.0, 0.0}, "exact")
+	c1.Insert([]float32{0.0, 1.0}, "ortho")
+	c1.Close()
+
+	c2, err := OpenCollection(rootDir, cfg.Name, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer c2.Close()
+
+	if c2.config.Quantization != cfg.Quantization {
+		t.Errorf("quantization lost on reopen: got %+v", c2.config.Quantization)
+	}
+	results, err := c2.Search([]float32{1.0, 0.0}, 1)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].VecID != idExact {
+		t.Fatalf("expected %s on top after replay, got %v", idExact, results)
+	}
+}
+
+func TestCreateCollection_InvalidQuantization(t *testing.T) {
+	_, err := CreateCollection(CollectionConfig{
+		Name:         "bad-quant",
+		Dimension:    2,
+		Metric:       types.Cosine,
+		IndexType:    types.LinearIndex,
+		DataType:     types.Text,
+		ModelName:    "model",
+		Quantization: index.QuantizationConfig{Rescore: true},
+	}, t.TempDir(), wal.SyncAlways)
+	if !errors.Is(err, ErrInvalidQuantization) {
+		t.Fatalf("expected ErrInvalidQuantization, got %v", err)
+	}
+}
+
+// Post-condition: a quantization the metric rejects leaves nothing on disk, the name stays usable.
+func TestCreateCollection_BinaryQuantizationRequiresCosine(t *testing.T) {
+	rootDir := t.TempDir()
+	cfg := CollectionConfig{
+		Name:         "binary",
+		Dimension:    2,
+		Metric:       types.Euclidean,
+		IndexType:    types.LinearIndex,
+		DataType:     types.Text,
+		ModelName:    "model",
+		Quantization: index.QuantizationConfig{Type: types.BinaryQuantization},
+	}
+	if _, err := CreateCollection(cfg, rootDir, wal.SyncAlways); !errors.Is(err, ErrInvalidQuantization) {
+		t.Fatalf("expected ErrInvalidQuantization, got %v", err)
+	}
+	if _, err := OpenCollection(rootDir, cfg.Name, wal.SyncAlways); !errors.Is(err, ErrCollectionNotFound) {
+		t.Fatalf("expected ErrCollectionNotFound after the rejected create, got %v", err)
+	}
+	cfg.Metric = types.Cosine
+	c, err := CreateCollection(cfg, rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("create with a valid config failed: %v", err)
+	}
+	c.Close()
+}
+
+// Post-condition: half precision with quantization is rejected before anything reaches disk.
+func TestCreateCollection_HalfPrecisionRejectsQuantization(t *testing.T) {
+	for _, prec := range []types.Precision{types.Float16Precision, types.BFloat16Precision} {
+		rootDir := t.TempDir()
+		cfg := CollectionConfig{
+			Name:         "half-quant",
+			Dimension:    2,
+			Metric:       types.Cosine,
+			IndexType:    types.LinearIndex,
+			DataType:     types.Text,
+			ModelName:    "model",
+			Precision:    prec,
+			Quantization: index.QuantizationConfig{Type: types.ScalarQuantization},
+		}
+		if _, err := CreateCollection(cfg, rootDir, wal.SyncAlways); !errors.Is(err, ErrInvalidPrecision) {
+			t.Fatalf("precision %v: expected ErrInvalidPrecision, got %v", prec, err)
+		}
+		if _, err := OpenCollection(rootDir, cfg.Name, wal.SyncAlways); !errors.Is(err, ErrCollectionNotFound) {
+			t.Fatalf("precision %v: expected ErrCollectionNotFound after the rejected create, got %v", prec, err)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Half precision collections: 2 byte components in the WAL, replayed identically
+// -----------------------------------------------------------------------------
+func TestCollection_HalfPrecision_SurvivesReplay(t *testing.T) {
+	for _, prec := range []types.Precision{types.Float16Precision, types.BFloat16Precision} {
+		rootDir := t.TempDir()
+		cfg := CollectionConfig{
+			Name:      "half",
+			Dimension: 3,
+			Metric:    types.Cosine,
+			IndexType: types.LinearIndex,
+			DataType:  types.Text,
+			ModelName: "model",
+			Precision: prec,
+		}
+		c1, err := CreateCollection(cfg, rootDir, wal.SyncAlways)
+		if err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+		// magnitude far beyond float16 range, only the normalized values reach the WAL
+		id, err := c1.Insert([]float32{1e6, 2e6, 0}, "big")
+		if err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+		before, _ := c1.index.Get(c1.extToInt[id])
+		c1.Close()
+
+		c2, err := OpenCollection(rootDir, cfg.Name, wal.SyncAlways)
+		if err != nil {
+			t.Fatalf("open failed: %v", err)
+		}
+		after, ok := c2.index.Get(c2.extToInt[id])
+		if !ok {
+			t.Fatalf("precision %v: vector lost on replay", prec)
+		}
+		for i, val := range after.Values() {
+			if d := val - before.Values()[i]; d > 1e-3 || d < -1e-3 {
+				t.Errorf("precision %v: component %d replayed as %v, was %v", prec, i, val, before.Values()[i])
+			}
+		}
+		c2.Close()
+	}
+}
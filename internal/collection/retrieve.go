@@ -0,0 +1,82 @@
+package collection
+
+import "fmt"
+
+// Record is one point as returned by Retrieve, parts that were not asked for or that the
+// point does not have are left empty
+type Record struct {
+	ID string
+	// default vector, as stored: normalized for normalizing metrics, rounded for half precision
+	// and reconstructed from the codes for quantized indexes
+	Vector []float32
+	// named dense field -> vector, only fields the point has
+	NamedVectors map[string][]float32
+	// multi vector field -> token vectors, only fields the point has
+	MultiVectors map[string][][]float32
+	// sparse vector of sparse and hybrid points, sorted by index
+	SparseIndices []uint32
+	SparseValues  []float32
+	Payload       any
+}
+
+// Retrieve returns the records of many points in one call, in the order of ids. Unknown ids are
+// skipped, so the result may be shorter than ids
+func (c *Collection) Retrieve(ids []string, withVector, withPayload bool) ([]Record, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	records := make([]Record, 0, len(ids))
+	for _, id := range ids {
+		internalID, ok := c.extToInt[id]
+		if !ok || c.expired(internalID) {
+			continue
+		}
+		rec := Record{ID: id}
+		if withVector {
+			if err := c.fillVectors(&rec, internalID); err != nil {
+				return nil, err
+			}
+		}
+		if withPayload {
+			payload, _, err := c.payloads.Get(id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read payload of %s: %w", id, err)
+			}
+			rec.Payload = payload
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// copies every vector the indexes hold for a point into rec, caller holds c.mu
+func (c *Collection) fillVectors(rec *Record, internalID int) error {
+	if c.index != nil {
+		vec, ok := c.index.Get(internalID)
+		if !ok {
+			return fmt.Errorf("id %s has no vector, internal corruption", rec.ID)
+		}
+		rec.Vector = vec.Values()
+	}
+	for name, idx := range c.fields {
+		if vec, ok := idx.Get(internalID); ok {
+			if rec.NamedVectors == nil {
+				rec.NamedVectors = make(map[string][]float32)
+			}
+			rec.NamedVectors[name] = vec.Values()
+		}
+	}
+	for name, idx := range c.multi {
+		if mv, ok := idx.Get(internalID); ok {
+			if rec.MultiVectors == nil {
+				rec.MultiVectors = make(map[string][][]float32)
+			}
+			rec.MultiVectors[name] = mv.Rows()
+		}
+	}
+	if c.sparse != nil {
+		if sv, ok := c.sparse.Get(internalID); ok {
+			rec.SparseIndices, rec.SparseValues = sv.Indices(), sv.Values()
+		}
+	}
+	return nil
+}
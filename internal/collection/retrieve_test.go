@@ -0,0 +1,63 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+)
+
+func TestCollection_Retrieve(t *testing.T) {
+	c, err := CreateCollection(multiVectorConfig(), t.TempDir(), wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	a, _ := c.InsertWithMultiVectors([]float32{3, 4}, map[string][]float32{"body": {0, 3, 4}}, map[string][][]float32{
+		"tokens": {{1, 0}, {0, 2}},
+	}, map[string]any{"name": "a"})
+	b, _ := c.Insert([]float32{0, 1}, "b")
+
+	records, err := c.Retrieve([]string{b, "missing", a}, true, true)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(records) != 2 || records[0].ID != b || records[1].ID != a {
+		t.Fatalf("expected b then a, got %+v", records)
+	}
+	// vectors come back as stored, normalized for cosine and euclidean, as is for inner product
+	want := Record{
+		ID:           a,
+		Vector:       []float32{0.6, 0.8},
+		NamedVectors: map[string][]float32{"body": {0, 0.6, 0.8}},
+		MultiVectors: map[string][][]float32{"tokens": {{1, 0}, {0, 2}}},
+		Payload:      map[string]any{"name": "a"},
+	}
+	if !reflect.DeepEqual(records[1], want) {
+		t.Errorf("expected %+v, got %+v", want, records[1])
+	}
+	if records[0].NamedVectors != nil || records[0].MultiVectors != nil || records[0].Payload != "b" {
+		t.Errorf("b has only a default vector, got %+v", records[0])
+	}
+
+	records, _ = c.Retrieve([]string{a}, false, false)
+	if !reflect.DeepEqual(records, []Record{{ID: a}}) {
+		t.Errorf("expected a bare record, got %+v", records)
+	}
+}
+
+func TestCollection_Retrieve_Sparse(t *testing.T) {
+	c, err := CreateCollection(hybridConfig(), t.TempDir(), wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	id, err := c.InsertHybrid([]float32{1, 0}, []uint32{7, 2}, []float32{0.5, 1}, nil)
+	if err != nil {
+		t.Fatalf("InsertHybrid failed: %v", err)
+	}
+	records, _ := c.Retrieve([]string{id}, true, false)
+	if len(records) != 1 || !reflect.DeepEqual(records[0].SparseIndices, []uint32{2, 7}) || !reflect.DeepEqual(records[0].SparseValues, []float32{1, 0.5}) {
+		t.Errorf("unexpected sparse record %+v", records)
+	}
+}
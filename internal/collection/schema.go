@@ -0,0 +1,232 @@
+package collection
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+// A PayloadSchema makes payloads typed: every insert must be a JSON object whose declared
+// fields have the declared types, and reads return those fields as Go types (int64 for
+// IntPayload, []string for StringListPayload, ...) instead of generic JSON values.
+// Collections without a schema keep storing whatever json.Marshal makes of the payload.
+
+// PayloadSchema declares the fields payloads of a collection may or must have
+type PayloadSchema struct {
+	Fields []PayloadField
+	// accept fields the schema does not declare, they are stored and read back untyped
+	AllowUnknown bool
+}
+
+// PayloadField declares one top level payload field
+type PayloadField struct {
+	Name     string
+	Type     types.PayloadType
+	Required bool
+}
+
+// field names must be unique and non empty, types known
+func validatePayloadSchema(cfg CollectionConfig) error {
+	if cfg.PayloadSchema == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(cfg.PayloadSchema.Fields))
+	for _, f := range cfg.PayloadSchema.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("%w: empty field name", ErrInvalidPayloadSchema)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("%w: duplicate field %s", ErrInvalidPayloadSchema, f.Name)
+		}
+		seen[f.Name] = true
+		switch f.Type {
+		case types.StringPayload, types.IntPayload, types.FloatPayload, types.BoolPayload, types.StringListPayload:
+		//ok valid input
+		default:
+			return fmt.Errorf("%w: field %s has unknown type %v", ErrInvalidPayloadSchema, f.Name, f.Type)
+		}
+	}
+	return nil
+}
+
+// validates a payload handed to an insert, returns its typed form and the JSON the WAL logs
+func (s *PayloadSchema) normalize(payload any) (map[string]any, []byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	typed, err := s.decode(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	// re-encoded from the typed map so the log holds exactly what reads return
+	raw, err = json.Marshal(typed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return typed, raw, nil
+}
+
+// decodes and validates the JSON of a payload into its typed form, a null payload is an
+// empty object and a null field counts as absent
+func (s *PayloadSchema) decode(raw []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	// numbers stay exact until the schema says what they are
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if value == nil {
+		value = map[string]any{}
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: payload must be a JSON object, got %T", ErrInvalidPayload, value)
+	}
+	typed := make(map[string]any, len(obj))
+	declared := make(map[string]bool, len(s.Fields))
+	for _, f := range s.Fields {
+		declared[f.Name] = true
+		val, ok := obj[f.Name]
+		if !ok || val == nil {
+			if f.Required {
+				return nil, fmt.Errorf("%w: missing required field %s", ErrInvalidPayload, f.Name)
+			}
+			continue
+		}
+		tv, err := convertField(f.Type, val)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %s: %v", ErrInvalidPayload, f.Name, err)
+		}
+		typed[f.Name] = tv
+	}
+	for name, val := range obj {
+		if declared[name] {
+			continue
+		}
+		if !s.AllowUnknown {
+			return nil, fmt.Errorf("%w: unknown field %s", ErrInvalidPayload, name)
+		}
+		typed[name] = untyped(val)
+	}
+	return typed, nil
+}
+
+// converts one decoded JSON value to the Go type of t
+func convertField(t types.PayloadType, val any) (any, error) {
+	switch t {
+	case types.StringPayload:
+		if s, ok := val.(string); ok {
+			return s, nil
+		}
+	case types.IntPayload:
+		if n, ok := val.(json.Number); ok {
+			i, err := n.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("expected %v, got %s", t, n)
+			}
+			return i, nil
+		}
+	case types.FloatPayload:
+		if n, ok := val.(json.Number); ok {
+			return n.Float64()
+		}
+	case types.BoolPayload:
+		if b, ok := val.(bool); ok {
+			return b, nil
+		}
+	case types.StringListPayload:
+		if list, ok := val.([]any); ok {
+			strs := make([]string, len(list))
+			for i, item := range list {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected %v, item %d is %s", t, i, jsonKind(item))
+				}
+				strs[i] = s
+			}
+			return strs, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %v, got %s", t, jsonKind(val))
+}
+
+// undeclared fields read back like payloads of schemaless collections, numbers as float64
+func untyped(val any) any {
+	switch v := val.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = untyped(v[i])
+		}
+		return v
+	case map[string]any:
+		for k := range v {
+			v[k] = untyped(v[k])
+		}
+		return v
+	default:
+		return val
+	}
+}
+
+// JSON kind of a decoded value for error messages
+func jsonKind(val any) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// validates and encodes an insert payload, without a schema it is stored as given
+func (c *Collection) encodePayload(payload any) (any, []byte, error) {
+	if c.config.PayloadSchema == nil {
+		raw, err := json.Marshal(payload)
+		return payload, raw, err
+	}
+	return c.config.PayloadSchema.normalize(payload)
+}
+
+// decodes a payload logged in the WAL, typed when the collection has a schema
+func (c *Collection) decodePayload(raw []byte) (any, error) {
+	if c.config.PayloadSchema != nil {
+		return c.config.PayloadSchema.decode(raw)
+	}
+	var payload any
+	// Only attempt to unmarshal if there is actual JSON data
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// GetInto decodes the payload of a point into dst, e.g. the struct it was inserted from
+func (c *Collection) GetInto(id string, dst any) (bool, error) {
+	payload, ok := c.Get(id)
+	if !ok {
+		return false, nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return true, err
+	}
+	return true, json.Unmarshal(raw, dst)
+}
@@ -0,0 +1,110 @@
+package collection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func schemaConfig(storage types.PayloadStorage) CollectionConfig {
+	return CollectionConfig{
+		Name:           "typed",
+		Dimension:      2,
+		Metric:         types.Cosine,
+		IndexType:      types.LinearIndex,
+		DataType:       types.Text,
+		ModelName:      "model",
+		PayloadStorage: storage,
+		PayloadSchema: &PayloadSchema{Fields: []PayloadField{
+			{Name: "title", Type: types.StringPayload, Required: true},
+			{Name: "views", Type: types.IntPayload},
+			{Name: "rating", Type: types.FloatPayload},
+			{Name: "draft", Type: types.BoolPayload},
+			{Name: "tags", Type: types.StringListPayload},
+		}},
+	}
+}
+
+type article struct {
+	Title  string   `json:"title"`
+	Views  int64    `json:"views"`
+	Rating float64  `json:"rating"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+func TestCreateCollection_PayloadSchemaValidation(t *testing.T) {
+	bad := []PayloadSchema{
+		{Fields: []PayloadField{{Name: "", Type: types.StringPayload}}},
+		{Fields: []PayloadField{{Name: "a", Type: types.StringPayload}, {Name: "a", Type: types.IntPayload}}},
+		{Fields: []PayloadField{{Name: "a"}}},
+	}
+	for i, schema := range bad {
+		cfg := schemaConfig(types.InMemoryPayloads)
+		cfg.PayloadSchema = &schema
+		if _, err := CreateCollection(cfg, t.TempDir(), wal.SyncAlways); !errors.Is(err, ErrInvalidPayloadSchema) {
+			t.Errorf("case %d: expected ErrInvalidPayloadSchema, got %v", i, err)
+		}
+	}
+}
+
+func TestCollection_PayloadSchema_RejectsInvalid(t *testing.T) {
+	c, err := CreateCollection(schemaConfig(types.InMemoryPayloads), t.TempDir(), wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	bad := []any{
+		nil,
+		"not an object",
+		map[string]any{"views": 3},
+		map[string]any{"title": 7},
+		map[string]any{"title": "t", "views": 1.5},
+		map[string]any{"title": "t", "tags": []any{"a", 1}},
+		map[string]any{"title": "t", "author": "x"},
+	}
+	for i, payload := range bad {
+		if _, err := c.Insert([]float32{1, 0}, payload); !errors.Is(err, ErrInvalidPayload) {
+			t.Errorf("case %d: expected ErrInvalidPayload, got %v", i, err)
+		}
+	}
+	if c.IDCounter() != 0 {
+		t.Errorf("rejected payloads must not consume ids, counter %d", c.IDCounter())
+	}
+}
+
+func TestCollection_PayloadSchema_TypedReads(t *testing.T) {
+	for _, storage := range []types.PayloadStorage{types.InMemoryPayloads, types.OnDiskPayloads} {
+		rootDir := t.TempDir()
+		c, err := CreateCollection(schemaConfig(storage), rootDir, wal.SyncAlways)
+		if err != nil {
+			t.Fatalf("CreateCollection failed: %v", err)
+		}
+		// beyond 2^53, a float64 round trip would change it
+		const views = int64(1<<60 + 1)
+		id, err := c.Insert([]float32{1, 0}, article{Title: "go", Views: views, Rating: 4.5, Tags: []string{"a", "b"}})
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		want := map[string]any{"title": "go", "views": views, "rating": 4.5, "tags": []string{"a", "b"}}
+		if got, _ := c.Get(id); !reflect.DeepEqual(got, want) {
+			t.Errorf("storage %d: expected %#v, got %#v", storage, want, got)
+		}
+		c.Close()
+
+		reopened, err := OpenCollection(rootDir, "typed", wal.SyncAlways)
+		if err != nil {
+			t.Fatalf("OpenCollection failed: %v", err)
+		}
+		if got, _ := reopened.Get(id); !reflect.DeepEqual(got, want) {
+			t.Errorf("storage %d after reopen: expected %#v, got %#v", storage, want, got)
+		}
+		var a article
+		if ok, err := reopened.GetInto(id, &a); !ok || err != nil || a.Views != views || a.Title != "go" {
+			t.Errorf("storage %d: GetInto returned %+v, %v, %v", storage, a, ok, err)
+		}
+		reopened.Close()
+	}
+}
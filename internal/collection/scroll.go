@@ -0,0 +1,80 @@
+package collection
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ScrollPage is one page of Scroll, NextCursor is empty once every point was returned
+type ScrollPage struct {
+	Records    []Record
+	NextCursor string
+}
+
+// Scroll pages through the points matching filter in insertion order. Pass an empty cursor for
+// the first page and NextCursor for the following ones. Each page is read under one consistent
+// view, and across pages the order is stable: points inserted meanwhile come after all older
+// ones and deleted points are simply skipped, so no point surviving the scroll is returned twice
+// or missed. Payloads are always returned, vectors when withVector is set
+func (c *Collection) Scroll(cursor string, limit int, filter *Filter, withVector bool) (ScrollPage, error) {
+	if limit <= 0 {
+		return ScrollPage{}, fmt.Errorf("invalid page limit %d", limit)
+	}
+	if err := filter.validate(); err != nil {
+		return ScrollPage{}, err
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return ScrollPage{}, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	// internal ids grow with every insert, which makes them the stable scroll order. Pages seek
+	// from the cursor through the ids handed out so far, a whole scroll visits each id once
+	var page ScrollPage
+	for internalID := after + 1; internalID <= c.idCounter; internalID++ {
+		extID, ok := c.intToExt[internalID]
+		if !ok {
+			// deleted, or never committed by a failed insert
+			continue
+		}
+		if len(page.Records) == limit {
+			// more points follow, the page ends after the last returned one
+			page.NextCursor = encodeCursor(after)
+			break
+		}
+		payload, _, err := c.payloads.Get(extID)
+		if err != nil {
+			return ScrollPage{}, fmt.Errorf("failed to read payload of %s: %w", extID, err)
+		}
+		// the cursor moves past filtered out and expired points too
+		after = internalID
+		if c.expired(internalID) || !filter.matches(payload) {
+			continue
+		}
+		rec := Record{ID: extID, Payload: payload}
+		if withVector {
+			if err := c.fillVectors(&rec, internalID); err != nil {
+				return ScrollPage{}, err
+			}
+		}
+		page.Records = append(page.Records, rec)
+	}
+	return page, nil
+}
+
+// cursors are opaque to callers, internally the last internal id a page looked at
+func encodeCursor(after int) string {
+	return strconv.FormatInt(int64(after), 36)
+}
+
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	after, err := strconv.ParseInt(cursor, 36, 64)
+	if err != nil || after < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	return int(after), nil
+}
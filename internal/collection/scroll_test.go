@@ -0,0 +1,118 @@
+package collection
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func scrollCollection(t *testing.T) *Collection {
+	t.Helper()
+	c, err := CreateCollection(CollectionConfig{Name: "scroll", Dimension: 2, Metric: types.Cosine, IndexType: types.LinearIndex, DataType: types.Text, ModelName: "t"}, t.TempDir(), wal.SyncOS)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// scrolls to the end and returns every id in page order
+func scrollAll(t *testing.T, c *Collection, limit int, filter *Filter) []string {
+	t.Helper()
+	var ids []string
+	cursor := ""
+	for {
+		page, err := c.Scroll(cursor, limit, filter, false)
+		if err != nil {
+			t.Fatalf("Scroll failed: %v", err)
+		}
+		if len(page.Records) > limit {
+			t.Fatalf("page of %d records exceeds limit %d", len(page.Records), limit)
+		}
+		for _, rec := range page.Records {
+			ids = append(ids, rec.ID)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func TestCollection_Scroll(t *testing.T) {
+	c := scrollCollection(t)
+	var want, even []string
+	for i := range 7 {
+		id, _ := c.Insert([]float32{1, float32(i)}, map[string]any{"n": i, "even": i%2 == 0})
+		want = append(want, id)
+		if i%2 == 0 {
+			even = append(even, id)
+		}
+	}
+	c.Delete(want[3])
+	want = append(want[:3], want[4:]...)
+
+	got := scrollAll(t, c, 2, nil)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d ids, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("position %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+	got = scrollAll(t, c, 3, &Filter{Must: []Condition{{Key: "even", Match: true}}})
+	if len(got) != len(even) {
+		t.Fatalf("expected %d even ids, got %v", len(even), got)
+	}
+
+	page, _ := c.Scroll("", 1, nil, true)
+	if len(page.Records[0].Vector) != 2 || page.Records[0].Payload == nil {
+		t.Errorf("expected vector and payload, got %+v", page.Records[0])
+	}
+	if _, err := c.Scroll("not a cursor!", 1, nil, false); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+	if _, err := c.Scroll("", 1, &Filter{Must: []Condition{{Key: "n"}}}, false); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("expected ErrInvalidFilter, got %v", err)
+	}
+}
+
+// points that exist for the whole scroll are returned exactly once, whatever writers do meanwhile
+func TestCollection_Scroll_ConcurrentWriters(t *testing.T) {
+	c := scrollCollection(t)
+	stable := make(map[string]bool)
+	for range 50 {
+		id, _ := c.Insert([]float32{1, 0}, "stable")
+		stable[id] = true
+	}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			id, _ := c.Insert([]float32{0, 1}, "churn")
+			c.Delete(id)
+		}
+	}()
+	seen := make(map[string]int)
+	for _, id := range scrollAll(t, c, 3, nil) {
+		seen[id]++
+	}
+	close(stop)
+	wg.Wait()
+	for id := range stable {
+		if seen[id] != 1 {
+			t.Errorf("stable point %s returned %d times", id, seen[id])
+		}
+	}
+}
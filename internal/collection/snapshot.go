@@ -0,0 +1,421 @@
+package collection
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// A Snapshot is a read view of the collection pinned to a WAL LSN. Writers keep going while it
+// is open and the snapshot keeps answering as of its LSN, so a long scroll or an export sees one
+// stable collection across many calls. Inserts need no bookkeeping: internal ids grow with every
+// insert, points newer than the snapshot are simply above its id. Deletes and payload updates
+// save the prior state of the point in an undo log while any snapshot is open, Release lets the
+// log drop what no open snapshot needs anymore. Each read still takes the read lock briefly.
+
+// Snapshot is a consistent read view, Release it once done
+type Snapshot struct {
+	c *Collection
+	// last WAL record the view includes
+	lsn uint64
+	// highest internal id that existed when the snapshot was taken
+	maxID int
+	// expiry checks use the time the snapshot was taken
+	at int64
+	// guarded by c.mu
+	released bool
+}
+
+// state of a point before a logged change, kept while a snapshot may still need it
+type undoEntry struct {
+	// LSN of the change, snapshots pinned before it see the saved state
+	lsn     uint64
+	extID   string
+	payload any
+	// set for deletes, the vectors the point had, payload left empty
+	deleted   *Record
+	expiresAt int64
+}
+
+// prior states of changed points, only kept while snapshots are open
+type undoLog struct {
+	// internal id -> changes in LSN order
+	points map[int][]undoEntry
+	// external id -> internal id of the points deleted while a snapshot was open
+	deleted map[string]int
+}
+
+// Snapshot pins the current state of the collection
+func (c *Collection) Snapshot() (*Snapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.wal == nil {
+		return nil, errors.New("collection has no wal to pin a snapshot to")
+	}
+	s := &Snapshot{c: c, lsn: c.wal.LSN(), maxID: c.idCounter, at: c.clock().UnixNano()}
+	if c.snapshots == nil {
+		c.snapshots = make(map[*Snapshot]struct{})
+	}
+	c.snapshots[s] = struct{}{}
+	return s, nil
+}
+
+// LSN returns the last WAL record the snapshot includes
+func (s *Snapshot) LSN() uint64 {
+	return s.lsn
+}
+
+// Release closes the snapshot, undo state only it needed is dropped. Safe to call more than once
+func (s *Snapshot) Release() {
+	c := s.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s.released {
+		return
+	}
+	s.released = true
+	delete(c.snapshots, s)
+	c.trimUndo()
+}
+
+// saves the state of a point before a change logged at lsn, a no-op without open snapshots.
+// Caller holds c.mu
+func (c *Collection) saveUndo(internalID int, lsn uint64, payload any, deleted *Record) {
+	if len(c.snapshots) == 0 {
+		return
+	}
+	if c.undo.points == nil {
+		c.undo.points = make(map[int][]undoEntry)
+		c.undo.deleted = make(map[string]int)
+	}
+	extID := c.intToExt[internalID]
+	c.undo.points[internalID] = append(c.undo.points[internalID], undoEntry{
+		lsn:       lsn,
+		extID:     extID,
+		payload:   payload,
+		deleted:   deleted,
+		expiresAt: c.expiry[internalID],
+	})
+	if deleted != nil {
+		c.undo.deleted[extID] = internalID
+	}
+}
+
+// drops undo entries older than every open snapshot, caller holds c.mu
+func (c *Collection) trimUndo() {
+	if len(c.snapshots) == 0 {
+		c.undo = undoLog{}
+		return
+	}
+	oldest := uint64(0)
+	for s := range c.snapshots {
+		if oldest == 0 || s.lsn < oldest {
+			oldest = s.lsn
+		}
+	}
+	for internalID, entries := range c.undo.points {
+		entries = slices.DeleteFunc(entries, func(e undoEntry) bool { return e.lsn <= oldest })
+		if len(entries) > 0 {
+			c.undo.points[internalID] = entries
+			continue
+		}
+		delete(c.undo.points, internalID)
+	}
+	for extID, internalID := range c.undo.deleted {
+		if _, ok := c.undo.points[internalID]; !ok {
+			delete(c.undo.deleted, extID)
+		}
+	}
+}
+
+// closes every open snapshot, the state they pinned is gone. Caller holds c.mu
+func (c *Collection) releaseSnapshots() {
+	for s := range c.snapshots {
+		s.released = true
+	}
+	c.snapshots = nil
+	c.undo = undoLog{}
+}
+
+// a point as the snapshot sees it
+type snapshotPoint struct {
+	internalID int
+	extID      string
+	payload    any
+	// vectors of points deleted after the snapshot, nil for live points
+	deleted *Record
+}
+
+// first undo entry of a point the snapshot predates, -1 if it is unchanged since. Caller holds c.mu
+func (s *Snapshot) firstChange(internalID int) ([]undoEntry, int) {
+	entries := s.c.undo.points[internalID]
+	return entries, slices.IndexFunc(entries, func(e undoEntry) bool { return e.lsn > s.lsn })
+}
+
+// whether the snapshot sees a point, decided from ids, the undo log and expiry times alone
+// without reading the payload. Caller holds c.mu
+func (s *Snapshot) visible(internalID int) bool {
+	if internalID > s.maxID {
+		return false
+	}
+	entries, first := s.firstChange(internalID)
+	if first < 0 {
+		// unchanged since the snapshot, unless it was deleted before it
+		_, ok := s.c.intToExt[internalID]
+		return ok && !s.expiredAt(s.c.expiry[internalID])
+	}
+	return !s.expiredAt(entries[first].expiresAt)
+}
+
+// state of a point as of the snapshot, false if it did not exist or had expired. Caller holds c.mu
+func (s *Snapshot) point(internalID int) (snapshotPoint, bool, error) {
+	c := s.c
+	if !s.visible(internalID) {
+		return snapshotPoint{}, false, nil
+	}
+	entries, first := s.firstChange(internalID)
+	if first < 0 {
+		extID := c.intToExt[internalID]
+		payload, _, err := c.payloads.Get(extID)
+		if err != nil {
+			return snapshotPoint{}, false, fmt.Errorf("failed to read payload of %s: %w", extID, err)
+		}
+		return snapshotPoint{internalID: internalID, extID: extID, payload: payload}, true, nil
+	}
+	before := entries[first]
+	// a delete is always the last change of a point
+	return snapshotPoint{
+		internalID: internalID,
+		extID:      before.extID,
+		payload:    before.payload,
+		deleted:    entries[len(entries)-1].deleted,
+	}, true, nil
+}
+
+func (s *Snapshot) expiredAt(expiresAt int64) bool {
+	return expiresAt != 0 && expiresAt <= s.at
+}
+
+// internal id of an external one as of the snapshot, caller holds c.mu
+func (s *Snapshot) lookup(id string) (int, bool) {
+	if internalID, ok := s.c.extToInt[id]; ok {
+		return internalID, true
+	}
+	internalID, ok := s.c.undo.deleted[id]
+	return internalID, ok
+}
+
+// internal ids the snapshot may see in ascending order, caller holds c.mu
+func (s *Snapshot) ids() []int {
+	ids := make([]int, 0, len(s.c.intToExt)+len(s.c.undo.deleted))
+	for internalID := range s.c.intToExt {
+		if internalID <= s.maxID {
+			ids = append(ids, internalID)
+		}
+	}
+	for _, internalID := range s.c.undo.deleted {
+		ids = append(ids, internalID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// takes the read lock, fails once the snapshot was released
+func (s *Snapshot) rlock() error {
+	s.c.mu.RLock()
+	if s.released {
+		s.c.mu.RUnlock()
+		return ErrSnapshotReleased
+	}
+	return nil
+}
+
+// record of a point with the parts asked for, caller holds c.mu
+func (s *Snapshot) record(p snapshotPoint, withVector, withPayload bool) (Record, error) {
+	rec := Record{ID: p.extID}
+	if withVector {
+		if p.deleted != nil {
+			rec = *p.deleted
+		} else if err := s.c.fillVectors(&rec, p.internalID); err != nil {
+			return Record{}, err
+		}
+	}
+	if withPayload {
+		rec.Payload = p.payload
+	}
+	return rec, nil
+}
+
+// Get returns the payload of a point as of the snapshot
+func (s *Snapshot) Get(id string) (any, bool) {
+	if err := s.rlock(); err != nil {
+		return nil, false
+	}
+	defer s.c.mu.RUnlock()
+	internalID, ok := s.lookup(id)
+	if !ok {
+		return nil, false
+	}
+	p, ok, err := s.point(internalID)
+	if err != nil || !ok {
+		return nil, false
+	}
+	return p.payload, true
+}
+
+// Retrieve is Collection.Retrieve as of the snapshot
+func (s *Snapshot) Retrieve(ids []string, withVector, withPayload bool) ([]Record, error) {
+	if err := s.rlock(); err != nil {
+		return nil, err
+	}
+	defer s.c.mu.RUnlock()
+	records := make([]Record, 0, len(ids))
+	for _, id := range ids {
+		internalID, ok := s.lookup(id)
+		if !ok {
+			continue
+		}
+		p, ok, err := s.point(internalID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		rec, err := s.record(p, withVector, withPayload)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// Scroll is Collection.Scroll as of the snapshot, every page sees the same points
+func (s *Snapshot) Scroll(cursor string, limit int, filter *Filter, withVector bool) (ScrollPage, error) {
+	if limit <= 0 {
+		return ScrollPage{}, fmt.Errorf("invalid page limit %d", limit)
+	}
+	if err := filter.validate(); err != nil {
+		return ScrollPage{}, err
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return ScrollPage{}, err
+	}
+	if err := s.rlock(); err != nil {
+		return ScrollPage{}, err
+	}
+	defer s.c.mu.RUnlock()
+	var page ScrollPage
+	for _, internalID := range s.ids() {
+		if internalID <= after {
+			continue
+		}
+		if len(page.Records) == limit {
+			page.NextCursor = encodeCursor(after)
+			break
+		}
+		after = internalID
+		p, ok, err := s.point(internalID)
+		if err != nil {
+			return ScrollPage{}, err
+		}
+		if !ok || !filter.matches(p.payload) {
+			continue
+		}
+		rec, err := s.record(p, withVector, true)
+		if err != nil {
+			return ScrollPage{}, err
+		}
+		page.Records = append(page.Records, rec)
+	}
+	return page, nil
+}
+
+// Count returns the number of points matching filter as of the snapshot, nil counts every point
+func (s *Snapshot) Count(filter *Filter) (int, error) {
+	if err := filter.validate(); err != nil {
+		return 0, err
+	}
+	if err := s.rlock(); err != nil {
+		return 0, err
+	}
+	defer s.c.mu.RUnlock()
+	count := 0
+	for _, internalID := range s.ids() {
+		p, ok, err := s.point(internalID)
+		if err != nil {
+			return 0, err
+		}
+		if ok && filter.matches(p.payload) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Search returns the k nearest points of the default vector as of the snapshot
+func (s *Snapshot) Search(queryVals []float32, k int) ([]Result, error) {
+	if err := s.rlock(); err != nil {
+		return []Result{}, err
+	}
+	defer s.c.mu.RUnlock()
+	c := s.c
+	field, err := c.denseField(DefaultVectorField)
+	if err != nil {
+		return []Result{}, err
+	}
+	impl, ok := vector.LookupMetric(field.metric)
+	if !ok {
+		return []Result{}, ErrInvalidMetric
+	}
+	query, err := field.newQuery(queryVals)
+	if err != nil {
+		return []Result{}, err
+	}
+	// live points the snapshot does not see: newer ones and ones expired when it was taken,
+	// only points with an expiry time can be the latter
+	hidden := make(map[int]bool)
+	for internalID := s.maxID + 1; internalID <= c.idCounter; internalID++ {
+		if _, ok := c.intToExt[internalID]; ok {
+			hidden[internalID] = true
+		}
+	}
+	for internalID := range c.expiry {
+		if !s.visible(internalID) {
+			hidden[internalID] = true
+		}
+	}
+	idxResult, err := field.idx.Search(query, k+len(hidden))
+	if err != nil {
+		return []Result{}, err
+	}
+	results, err := c.toResults(dropExpired(idxResult, hidden, k))
+	if err != nil {
+		return []Result{}, err
+	}
+	// points deleted after the snapshot are no longer indexed, their saved vectors are scored
+	for _, internalID := range slices.Sorted(maps.Values(c.undo.deleted)) {
+		p, ok, err := s.point(internalID)
+		if err != nil {
+			return []Result{}, err
+		}
+		if !ok || p.deleted == nil || p.deleted.Vector == nil {
+			continue
+		}
+		score := impl.Score(query.Values(), p.deleted.Vector)
+		results = append(results, Result{VecID: p.extID, Score: float64(score)})
+	}
+	slices.SortStableFunc(results, func(a, b Result) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if k < len(results) {
+		results = results[:k]
+	}
+	return results, nil
+}
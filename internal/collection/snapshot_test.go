@@ -0,0 +1,181 @@
+package collection
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/payload"
+)
+
+func TestSnapshot_IsolatedFromWriters(t *testing.T) {
+	c := scrollCollection(t)
+	a, _ := c.Insert([]float32{1, 0}, map[string]any{"v": "a1"})
+	b, _ := c.Insert([]float32{0, 1}, map[string]any{"v": "b1"})
+	d, _ := c.Insert([]float32{1, 1}, map[string]any{"v": "d1"})
+
+	s, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	defer s.Release()
+	if s.LSN() != 3 {
+		t.Errorf("expected the snapshot at lsn 3, got %d", s.LSN())
+	}
+
+	// writers keep going after the snapshot
+	if err := c.SetPayload(a, map[string]any{"v": "a2"}); err != nil {
+		t.Fatalf("SetPayload failed: %v", err)
+	}
+	if err := c.OverwritePayload(a, map[string]any{"v": "a3"}); err != nil {
+		t.Fatalf("OverwritePayload failed: %v", err)
+	}
+	if err := c.Delete(b); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	e, _ := c.Insert([]float32{0.1, 1}, map[string]any{"v": "e1"})
+
+	if p, _ := c.Get(a); p.(map[string]any)["v"] != "a3" {
+		t.Errorf("collection: expected the latest payload of a, got %v", p)
+	}
+	if p, ok := s.Get(a); !ok || p.(map[string]any)["v"] != "a1" {
+		t.Errorf("snapshot: expected a as of the snapshot, got %v, %v", p, ok)
+	}
+	if p, ok := s.Get(b); !ok || p.(map[string]any)["v"] != "b1" {
+		t.Errorf("snapshot: expected the deleted point b, got %v, %v", p, ok)
+	}
+	if _, ok := s.Get(e); ok {
+		t.Error("snapshot: returned a point inserted after it")
+	}
+
+	if n, _ := s.Count(nil); n != 3 {
+		t.Errorf("snapshot count: expected 3, got %d", n)
+	}
+	if n, _ := s.Count(&Filter{Must: []Condition{{Key: "v", Match: "a1"}}}); n != 1 {
+		t.Errorf("snapshot filtered count: expected 1, got %d", n)
+	}
+
+	var ids []string
+	cursor := ""
+	for {
+		page, err := s.Scroll(cursor, 1, nil, true)
+		if err != nil {
+			t.Fatalf("Scroll failed: %v", err)
+		}
+		for _, rec := range page.Records {
+			if len(rec.Vector) != 2 {
+				t.Errorf("scroll: expected the vector of %s, got %v", rec.ID, rec.Vector)
+			}
+			ids = append(ids, rec.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+		// a write between pages does not show up in the snapshot
+		c.Insert([]float32{1, 0}, nil)
+	}
+	if len(ids) != 3 || ids[0] != a || ids[1] != b || ids[2] != d {
+		t.Errorf("snapshot scroll: expected [%s %s %s], got %v", a, b, d, ids)
+	}
+
+	records, err := s.Retrieve([]string{b, e}, true, true)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(records) != 1 || records[0].ID != b || records[0].Vector == nil || records[0].Payload == nil {
+		t.Errorf("snapshot retrieve: expected b with vector and payload, got %+v", records)
+	}
+
+	// b was deleted after the snapshot, it is still the nearest point as of it
+	results, err := s.Search([]float32{0, 1}, 2)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if got := resultIDs(results); len(got) != 2 || got[0] != b || got[1] != d {
+		t.Errorf("snapshot search: expected [%s %s], got %v", b, d, got)
+	}
+	results, _ = c.Search([]float32{0, 1}, 1)
+	if got := resultIDs(results); len(got) != 1 || got[0] != e {
+		t.Errorf("collection search: expected [%s], got %v", e, got)
+	}
+}
+
+func TestSnapshot_Release(t *testing.T) {
+	c := scrollCollection(t)
+	a, _ := c.Insert([]float32{1, 0}, map[string]any{"v": 1})
+	b, _ := c.Insert([]float32{0, 1}, map[string]any{"v": 1})
+
+	// nothing is saved while no snapshot is open
+	c.Delete(a)
+	if len(c.undo.points) != 0 {
+		t.Errorf("expected no undo state without snapshots, got %v", c.undo.points)
+	}
+
+	old, _ := c.Snapshot()
+	c.SetPayload(b, map[string]any{"v": 2})
+	young, _ := c.Snapshot()
+	c.SetPayload(b, map[string]any{"v": 3})
+	if n := len(c.undo.points[c.extToInt[b]]); n != 2 {
+		t.Fatalf("expected 2 undo entries for b, got %d", n)
+	}
+
+	old.Release()
+	old.Release()
+	if n := len(c.undo.points[c.extToInt[b]]); n != 1 {
+		t.Errorf("expected the entry only the old snapshot needed dropped, %d left", n)
+	}
+	if p, _ := young.Get(b); p.(map[string]any)["v"] != float64(2) {
+		t.Errorf("young snapshot: expected v 2, got %v", p)
+	}
+	if _, err := old.Count(nil); !errors.Is(err, ErrSnapshotReleased) {
+		t.Errorf("expected ErrSnapshotReleased, got %v", err)
+	}
+
+	young.Release()
+	if c.undo.points != nil || len(c.snapshots) != 0 {
+		t.Errorf("expected no undo state after the last release, got %v", c.undo.points)
+	}
+}
+
+// payload store counting Get calls
+type countingStore struct {
+	payload.PayloadStore
+	gets int
+}
+
+func (cs *countingStore) Get(id string) (any, bool, error) {
+	cs.gets++
+	return cs.PayloadStore.Get(id)
+}
+
+// Post-condition: Search decides what the snapshot sees without reading payloads, newer and
+// expired points stay hidden.
+func TestSnapshot_SearchReadsNoPayloads(t *testing.T) {
+	c := scrollCollection(t)
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	c.now = clock.now
+	a, _ := c.Insert([]float32{1, 0}, map[string]any{"v": "a"})
+	c.InsertWithExpiry([]float32{1, 0.01}, nil, clock.t.Add(time.Minute))
+	clock.t = clock.t.Add(time.Hour)
+
+	s, err := c.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	defer s.Release()
+	c.Insert([]float32{1, 0.02}, nil)
+
+	store := &countingStore{PayloadStore: c.payloads}
+	c.payloads = store
+	results, err := s.Search([]float32{1, 0}, 3)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if got := resultIDs(results); len(got) != 1 || got[0] != a {
+		t.Errorf("expected only %s, got %v", a, got)
+	}
+	if store.gets != 0 {
+		t.Errorf("Search read %d payloads", store.gets)
+	}
+}
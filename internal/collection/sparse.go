@@ -0,0 +1,136 @@
+package collection
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+	"github.com/google/uuid"
+)
+
+// Sparse collections (IndexType InvertedIndex) hold index/value pairs over a vocabulary
+// of Dimension entries instead of dense vectors, e.g. SPLADE or BM25 style term weights.
+// They share ids, payloads, Delete and the WAL with dense collections, only the vector
+// half of the API differs.
+
+// InsertSparse adds a sparse vector, indices must be unique and below the collection dimension
+func (c *Collection) InsertSparse(indices []uint32, values []float32, payload any) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 1. Validation phase (Fails fast, no state changed)
+	// hybrid collections need the dense half too, they insert through InsertHybrid
+	if c.sparse == nil || c.index != nil {
+		return "", ErrVectorKindMismatch
+	}
+	sparseVec, err := c.newSparseVector(indices, values)
+	if err != nil {
+		return "", err
+	}
+	payload, metaData, err := c.encodePayload(payload)
+	if err != nil {
+		return "", err
+	}
+	// sparse points only expire by the collection default
+	expiresAt, err := c.expiryFor(time.Time{})
+	if err != nil {
+		return "", err
+	}
+
+	// 2. Prepare data, the counter is only committed once the index accepted the vector
+	externalID := uuid.NewString()
+	internalID := c.idCounter + 1
+
+	// 3. Write to WAL, the sorted pairs of the constructed vector are what replay rebuilds
+	_, err = c.wal.AppendInsertSparseExpiring(externalID, uint64(internalID), sparseVec.Indices(), sparseVec.Values(), expiresAt, metaData)
+	if err != nil {
+		return "", err
+	}
+
+	// 4. Memory Mutation, compensated with a WAL delete like Insert
+	added, err := c.sparse.Add(internalID, sparseVec)
+	if err != nil || !added {
+		_, walErr := c.wal.AppendDelete(externalID, uint64(internalID))
+		if walErr != nil {
+			return "", fmt.Errorf("index failed: %v, critical wal rollback failed: %v", err, walErr)
+		}
+		if err != nil {
+			return "", err
+		}
+		return "", ErrInternalIDCollision
+	}
+
+	// 5. Store the payload
+	if err := c.storePayload(externalID, internalID, payload, metaData); err != nil {
+		return "", err
+	}
+
+	// 6. Finalize State
+	c.idCounter = internalID
+	c.extToInt[externalID] = internalID
+	c.intToExt[internalID] = externalID
+	c.setExpiry(internalID, expiresAt)
+	c.ensureReaper()
+
+	return externalID, nil
+}
+
+// SearchSparse returns the k vectors with the highest sparse dot product against the query,
+// vectors sharing no term with the query are not returned
+func (c *Collection) SearchSparse(indices []uint32, values []float32, k int) ([]Result, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.sparse == nil {
+		return []Result{}, ErrVectorKindMismatch
+	}
+	query, err := c.newSparseVector(indices, values)
+	if err != nil {
+		return []Result{}, err
+	}
+	expired := c.expiredIDs()
+	idxResult, err := c.sparse.Search(query, k+len(expired))
+	if err != nil {
+		return []Result{}, err
+	}
+	return c.toResults(dropExpired(idxResult, expired, k))
+}
+
+// cosine and dot collections score unit length sparse vectors like their dense counterparts
+// hybrid collections take metric and vocabulary from their sparse config
+func (c *Collection) newSparseVector(indices []uint32, values []float32) (*vector.SparseVector, error) {
+	metric, vocabulary := c.config.Metric, c.config.Dimension
+	if c.config.Sparse != nil {
+		metric, vocabulary = c.config.Sparse.metric(), c.config.Sparse.Vocabulary
+	}
+	impl, ok := vector.LookupMetric(metric)
+	if !ok {
+		return nil, ErrInvalidMetric
+	}
+	if impl.Normalizes() {
+		return vector.NewNormalizedSparseVector(indices, values, vocabulary)
+	}
+	return vector.NewSparseVector(indices, values, vocabulary)
+}
+
+// removes an internal id from every index the collection holds, idempotent like the indexes
+func (c *Collection) deleteFromIndex(internalID int) error {
+	if c.index != nil {
+		if err := c.index.Delete(internalID); err != nil {
+			return err
+		}
+	}
+	for name, idx := range c.fields {
+		if err := idx.Delete(internalID); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	for name, idx := range c.multi {
+		if err := idx.Delete(internalID); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	if c.sparse != nil {
+		return c.sparse.Delete(internalID)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package collection
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func setupSparseCollection(t *testing.T, rootDir string) *Collection {
+	t.Helper()
+	c, err := CreateCollection(CollectionConfig{
+		Name:      "sparse",
+		Dimension: 30000,
+		Metric:    types.InnerProduct,
+		IndexType: types.InvertedIndex,
+		DataType:  types.Text,
+		ModelName: "splade",
+	}, rootDir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	return c
+}
+
+// Post-condition: a metric the inverted index cannot score with leaves nothing on disk.
+func TestCreateCollection_Sparse_RequiresDotMetric(t *testing.T) {
+	rootDir := t.TempDir()
+	cfg := CollectionConfig{
+		Name:      "sparse",
+		Dimension: 100,
+		Metric:    types.Euclidean,
+		IndexType: types.InvertedIndex,
+		DataType:  types.Text,
+		ModelName: "splade",
+	}
+	if _, err := CreateCollection(cfg, rootDir, wal.SyncAlways); !errors.Is(err, ErrInvalidSparseConfig) {
+		t.Fatalf("expected ErrInvalidSparseConfig, got %v", err)
+	}
+	if _, err := OpenCollection(rootDir, cfg.Name, wal.SyncAlways); !errors.Is(err, ErrCollectionNotFound) {
+		t.Fatalf("expected ErrCollectionNotFound after the rejected create, got %v", err)
+	}
+	setupSparseCollection(t, rootDir).Close()
+}
+
+func TestCollection_Sparse_InsertSearchDelete(t *testing.T) {
+	c := setupSparseCollection(t, t.TempDir())
+	defer c.Close()
+
+	a, err := c.InsertSparse([]uint32{10, 200}, []float32{1, 2}, map[string]string{"doc": "a"})
+	if err != nil {
+		t.Fatalf("InsertSparse failed: %v", err)
+	}
+	b, _ := c.InsertSparse([]uint32{200, 29999}, []float32{0.5, 4}, nil)
+	if _, err := c.InsertSparse([]uint32{30000}, []float32{1}, nil); err == nil {
+		t.Error("expected error for index outside the vocabulary")
+	}
+
+	results, err := c.SearchSparse([]uint32{200}, []float32{1}, 5)
+	if err != nil {
+		t.Fatalf("SearchSparse failed: %v", err)
+	}
+	if len(results) != 2 || results[0].VecID != a || results[1].VecID != b {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if err := c.Delete(a); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	results, _ = c.SearchSparse([]uint32{200}, []float32{1}, 5)
+	if len(results) != 1 || results[0].VecID != b {
+		t.Errorf("deleted vector still returned %+v", results)
+	}
+}
+
+func TestCollection_Sparse_VectorKindMismatch(t *testing.T) {
+	c := setupSparseCollection(t, t.TempDir())
+	defer c.Close()
+	if _, err := c.Insert([]float32{1}, nil); !errors.Is(err, ErrVectorKindMismatch) {
+		t.Errorf("expected ErrVectorKindMismatch for dense insert, got %v", err)
+	}
+	if _, err := c.Search([]float32{1}, 1); !errors.Is(err, ErrVectorKindMismatch) {
+		t.Errorf("expected ErrVectorKindMismatch for dense search, got %v", err)
+	}
+
+	dense, _ := setupTestCollection(t)
+	defer dense.Close()
+	if _, err := dense.InsertSparse([]uint32{1}, []float32{1}, nil); !errors.Is(err, ErrVectorKindMismatch) {
+		t.Errorf("expected ErrVectorKindMismatch for sparse insert, got %v", err)
+	}
+}
+
+func TestCollection_Sparse_Recovery(t *testing.T) {
+	rootDir := t.TempDir()
+	c := setupSparseCollection(t, rootDir)
+	keep, _ := c.InsertSparse([]uint32{7, 8}, []float32{1, 1}, map[string]any{"n": 1})
+	gone, _ := c.InsertSparse([]uint32{7}, []float32{3}, nil)
+	c.Delete(gone)
+	c.Close()
+
+	reopened, err := OpenCollection(rootDir, "sparse", wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	defer reopened.Close()
+	results, err := reopened.SearchSparse([]uint32{7}, []float32{2}, 5)
+	if err != nil {
+		t.Fatalf("SearchSparse failed: %v", err)
+	}
+	if len(results) != 1 || results[0].VecID != keep || results[0].Score != 2 {
+		t.Errorf("unexpected results after replay %+v", results)
+	}
+	if reopened.IDCounter() != 2 {
+		t.Errorf("expected id counter 2, got %d", reopened.IDCounter())
+	}
+}
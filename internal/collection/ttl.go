@@ -0,0 +1,192 @@
+package collection
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/index"
+)
+
+// Points may carry an expiry time, set per insert with InsertWithExpiry or derived from the
+// collection's DefaultTTL. The time is logged with the insert record so it survives LoadState.
+// An expired point is invisible right away: searches, Get, Retrieve, Scroll and the aggregates
+// skip it. The reaper removes it for good later, with a regular WAL delete.
+
+// how often the reaper looks for expired points unless the config says otherwise
+const defaultReapInterval = time.Minute
+
+func validateTTL(cfg CollectionConfig) error {
+	if cfg.DefaultTTL < 0 {
+		return fmt.Errorf("%w: negative default ttl %v", ErrInvalidTTL, cfg.DefaultTTL)
+	}
+	if cfg.ReapInterval < 0 {
+		return fmt.Errorf("%w: negative reap interval %v", ErrInvalidTTL, cfg.ReapInterval)
+	}
+	return nil
+}
+
+// InsertWithExpiry adds a point that expires at expiresAt, a zero time falls back to the
+// collection's DefaultTTL
+func (c *Collection) InsertWithExpiry(vecVals []float32, payload any, expiresAt time.Time) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.insertPoint(pointInput{dense: vecVals, expiresAt: expiresAt}, payload)
+}
+
+// ExpiresAt returns the expiry time of a point, false for unknown and expired points and for
+// points that never expire
+func (c *Collection) ExpiresAt(id string) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	internalID, ok := c.extToInt[id]
+	if !ok || c.expired(internalID) {
+		return time.Time{}, false
+	}
+	expiresAt, ok := c.expiry[internalID]
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, expiresAt), true
+}
+
+// ReapExpired deletes every expired point and returns how many were deleted. The write lock
+// is only taken when the read lock shows expired points
+func (c *Collection) ReapExpired() (int, error) {
+	c.mu.RLock()
+	none := len(c.expiredIDs()) == 0
+	c.mu.RUnlock()
+	if none {
+		return 0, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expired := c.expiredIDs()
+	// oldest first so the WAL reads like the points were deleted in insertion order
+	ids := make([]int, 0, len(expired))
+	for internalID := range expired {
+		ids = append(ids, internalID)
+	}
+	slices.SortFunc(ids, cmp.Compare)
+	for i, internalID := range ids {
+		if err := c.deletePoint(c.intToExt[internalID], internalID); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// expiry time of a new point in unix nanoseconds, 0 never expires. Caller holds c.mu
+func (c *Collection) expiryFor(expiresAt time.Time) (int64, error) {
+	now := c.clock()
+	if expiresAt.IsZero() {
+		if c.config.DefaultTTL == 0 {
+			return 0, nil
+		}
+		return now.Add(c.config.DefaultTTL).UnixNano(), nil
+	}
+	if !expiresAt.After(now) {
+		return 0, fmt.Errorf("%w: expiry %v is not in the future", ErrInvalidTTL, expiresAt)
+	}
+	return expiresAt.UnixNano(), nil
+}
+
+// records the expiry time of a point, 0 never expires. Caller holds c.mu
+func (c *Collection) setExpiry(internalID int, expiresAt int64) {
+	if expiresAt == 0 {
+		return
+	}
+	if c.expiry == nil {
+		c.expiry = make(map[int]int64)
+	}
+	c.expiry[internalID] = expiresAt
+}
+
+func (c *Collection) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// reports whether a point has expired, caller holds c.mu
+func (c *Collection) expired(internalID int) bool {
+	expiresAt, ok := c.expiry[internalID]
+	return ok && expiresAt <= c.clock().UnixNano()
+}
+
+// internal ids of the expired points the reaper has not deleted yet, nil when there are none.
+// Caller holds c.mu
+func (c *Collection) expiredIDs() map[int]bool {
+	if len(c.expiry) == 0 {
+		return nil
+	}
+	now := c.clock().UnixNano()
+	var expired map[int]bool
+	for internalID, expiresAt := range c.expiry {
+		if expiresAt <= now {
+			if expired == nil {
+				expired = make(map[int]bool)
+			}
+			expired[internalID] = true
+		}
+	}
+	return expired
+}
+
+// drops the expired ids from results fetched with k plus one per expired point, at most k remain
+func dropExpired(results []index.SearchResult, expired map[int]bool, k int) []index.SearchResult {
+	if len(expired) > 0 {
+		results = slices.DeleteFunc(results, func(res index.SearchResult) bool {
+			return expired[res.VecId]
+		})
+	}
+	if k < len(results) {
+		results = results[:k]
+	}
+	return results
+}
+
+// background goroutine calling ReapExpired every interval until stopped
+type reaper struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// starts the reaper once points can expire: the collection has a DefaultTTL or a point with an
+// expiry time. Collections without either never run one. Called after inserts and once the
+// WAL is replayed, not per replayed record. Close stops it, caller holds c.mu
+func (c *Collection) ensureReaper() {
+	if c.reaper != nil || c.closing || (c.config.DefaultTTL == 0 && len(c.expiry) == 0) {
+		return
+	}
+	interval := c.config.ReapInterval
+	if interval == 0 {
+		interval = defaultReapInterval
+	}
+	r := &reaper{stop: make(chan struct{}), done: make(chan struct{})}
+	c.reaper = r
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				// a failed delete is retried on the next tick
+				c.ReapExpired()
+			}
+		}
+	}()
+}
+
+// stops the reaper and waits for a running reap to finish, safe to call more than once
+func (r *reaper) shutdown() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+}
@@ -0,0 +1,223 @@
+package collection
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func ttlConfig() CollectionConfig {
+	return CollectionConfig{
+		Name: "sessions", Dimension: 2, Metric: types.Cosine, IndexType: types.LinearIndex, DataType: types.Text, ModelName: "t",
+		DefaultTTL: time.Hour,
+		// the tests reap by hand
+		ReapInterval: time.Hour,
+	}
+}
+
+// a clock the test moves forward by hand
+type fakeClock struct{ t time.Time }
+
+func (f *fakeClock) now() time.Time { return f.t }
+
+func TestCollection_TTL(t *testing.T) {
+	c, err := CreateCollection(ttlConfig(), t.TempDir(), wal.SyncOS)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	c.now = clock.now
+
+	short, err := c.InsertWithExpiry([]float32{1, 0}, map[string]any{"user": "a"}, clock.t.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("InsertWithExpiry failed: %v", err)
+	}
+	long, _ := c.Insert([]float32{1, 0.1}, map[string]any{"user": "b"})
+	if at, ok := c.ExpiresAt(long); !ok || !at.Equal(clock.t.Add(time.Hour)) {
+		t.Errorf("expected the default ttl expiry %v, got %v, %v", clock.t.Add(time.Hour), at, ok)
+	}
+
+	// the short lived point is hidden as soon as it expires, before any reap
+	clock.t = clock.t.Add(2 * time.Minute)
+	results, _ := c.Search([]float32{1, 0}, 2)
+	if got := resultIDs(results); len(got) != 1 || got[0] != long {
+		t.Errorf("search: expected only %s, got %v", long, got)
+	}
+	if _, ok := c.Get(short); ok {
+		t.Error("Get returned an expired point")
+	}
+	if _, ok := c.ExpiresAt(short); ok {
+		t.Error("ExpiresAt returned an expired point")
+	}
+	if records, _ := c.Retrieve([]string{short, long}, false, true); len(records) != 1 || records[0].ID != long {
+		t.Errorf("retrieve: expected only %s, got %+v", long, records)
+	}
+	if ids := scrollAll(t, c, 10, nil); len(ids) != 1 || ids[0] != long {
+		t.Errorf("scroll: expected only %s, got %v", long, ids)
+	}
+	if n, _ := c.Count(nil); n != 1 {
+		t.Errorf("count: expected 1, got %d", n)
+	}
+	if err := c.SetPayload(short, map[string]any{"x": 1}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("update of an expired point: expected ErrNotFound, got %v", err)
+	}
+	if err := c.Delete(short); !errors.Is(err, ErrNotFound) {
+		t.Errorf("delete of an expired point: expected ErrNotFound, got %v", err)
+	}
+
+	n, err := c.ReapExpired()
+	if err != nil || n != 1 {
+		t.Fatalf("expected one reaped point, got %d, %v", n, err)
+	}
+	if _, ok := c.ExtToInt()[short]; ok {
+		t.Error("reaped point still mapped")
+	}
+	clock.t = clock.t.Add(time.Hour)
+	if results, _ := c.Search([]float32{1, 0}, 2); len(results) != 0 {
+		t.Errorf("expected no live points, got %v", results)
+	}
+	if n, _ := c.ReapExpired(); n != 1 || len(c.ExtToInt()) != 0 {
+		t.Errorf("expected the last point reaped, got %d and %v", n, c.ExtToInt())
+	}
+}
+
+func TestCollection_TTL_SurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	c, err := CreateCollection(ttlConfig(), dir, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	expiresAt := time.Now().Add(10 * time.Minute).Truncate(time.Nanosecond)
+	id, err := c.InsertWithExpiry([]float32{1, 0}, nil, expiresAt)
+	if err != nil {
+		t.Fatalf("InsertWithExpiry failed: %v", err)
+	}
+	c.Close()
+
+	c, err = OpenCollection(dir, "sessions", wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	if at, ok := c.ExpiresAt(id); !ok || !at.Equal(expiresAt) {
+		t.Errorf("expected expiry %v after reopen, got %v, %v", expiresAt, at, ok)
+	}
+	c.now = func() time.Time { return expiresAt }
+	if _, ok := c.Get(id); ok {
+		t.Error("replayed point did not expire")
+	}
+	if n, err := c.ReapExpired(); n != 1 || err != nil {
+		t.Fatalf("expected one reaped point, got %d, %v", n, err)
+	}
+	c.Close()
+
+	// the reaper logged a regular delete
+	c, err = OpenCollection(dir, "sessions", wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	defer c.Close()
+	if len(c.ExtToInt()) != 0 {
+		t.Errorf("reaped point came back: %v", c.ExtToInt())
+	}
+}
+
+func TestCollection_TTL_BackgroundReaper(t *testing.T) {
+	cfg := ttlConfig()
+	cfg.ReapInterval = 5 * time.Millisecond
+	c, err := CreateCollection(cfg, t.TempDir(), wal.SyncOS)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	id, err := c.InsertWithExpiry([]float32{1, 0}, nil, time.Now().Add(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("InsertWithExpiry failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := c.ExtToInt()[id]; !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("reaper did not delete the expired point")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Invariant: a reaper only runs once points can expire.
+// Post-condition: the first expiring insert starts it and a reopen with expiring points too.
+func TestCollection_TTL_ReaperStartsOnDemand(t *testing.T) {
+	dir := t.TempDir()
+	cfg := ttlConfig()
+	cfg.DefaultTTL = 0
+	cfg.ReapInterval = 5 * time.Millisecond
+	c, err := CreateCollection(cfg, dir, wal.SyncOS)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	c.Insert([]float32{1, 0}, nil)
+	if c.reaper != nil {
+		t.Fatal("reaper started without DefaultTTL or expiring points")
+	}
+	id, err := c.InsertWithExpiry([]float32{0, 1}, nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("InsertWithExpiry failed: %v", err)
+	}
+	if c.reaper == nil {
+		t.Fatal("expiring insert did not start the reaper")
+	}
+	c.Close()
+
+	c, err = OpenCollection(dir, cfg.Name, wal.SyncOS)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	if c.reaper == nil {
+		t.Fatal("reopen with an expiring point did not start the reaper")
+	}
+	c.mu.Lock()
+	c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	c.mu.Unlock()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := c.ExtToInt()[id]; !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("reaper did not delete the expired point")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	c.Close()
+
+	// no expiring point is left and there is no DefaultTTL
+	c, err = OpenCollection(dir, cfg.Name, wal.SyncOS)
+	if err != nil {
+		t.Fatalf("OpenCollection failed: %v", err)
+	}
+	defer c.Close()
+	if c.reaper != nil {
+		t.Error("reaper started for a collection where nothing can expire")
+	}
+}
+
+func TestCollection_TTL_Invalid(t *testing.T) {
+	c, err := CreateCollection(ttlConfig(), t.TempDir(), wal.SyncOS)
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	defer c.Close()
+	if _, err := c.InsertWithExpiry([]float32{1, 0}, nil, time.Now().Add(-time.Second)); !errors.Is(err, ErrInvalidTTL) {
+		t.Errorf("expected ErrInvalidTTL for a past expiry, got %v", err)
+	}
+	cfg := ttlConfig()
+	cfg.DefaultTTL = -time.Second
+	if _, err := CreateCollection(cfg, t.TempDir(), wal.SyncOS); !errors.Is(err, ErrInvalidTTL) {
+		t.Errorf("expected ErrInvalidTTL for a negative default ttl, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package index
+
+import (
+	"math"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// sign bits of every vector, slot i occupies bits[i*words : (i+1)*words]
+// with rescoring it only drives candidate selection and the float32 arena stays the source for
+// exact scores, without it the bits are all the index keeps
+type binaryArena struct {
+	dim   int
+	words int
+	bits  []uint64
+}
+
+func newBinaryArena(dim int) *binaryArena {
+	return &binaryArena{dim: dim, words: vector.BinaryWords(dim)}
+}
+
+// appends one empty slot
+func (ba *binaryArena) grow() {
+	ba.bits = append(ba.bits, make([]uint64, ba.words)...)
+}
+
+func (ba *binaryArena) slotBits(slot int) []uint64 {
+	return ba.bits[slot*ba.words : (slot+1)*ba.words : (slot+1)*ba.words]
+}
+
+func (ba *binaryArena) set(slot int, values []float32) {
+	vector.PackSigns(values, ba.slotBits(slot))
+}
+
+// sign bits of a query in the layout of the stored slots
+func (ba *binaryArena) pack(query []float32) []uint64 {
+	qBits := make([]uint64, ba.words)
+	vector.PackSigns(query, qBits)
+	return qBits
+}
+
+// hamming distance of packed query bits to the slots first, first+1, ..., one per entry of out
+func (ba *binaryArena) distances(qBits []uint64, first int, out []int) {
+	for i := range out {
+		out[i] = vector.HammingDistance(qBits, ba.slotBits(first+i))
+	}
+}
+
+// unit vector with the stored signs, every component +-1/sqrt(dim)
+func (ba *binaryArena) values(slot int) []float32 {
+	bits := ba.slotBits(slot)
+	mag := float32(1 / math.Sqrt(float64(ba.dim)))
+	vals := make([]float32, ba.dim)
+	for i := range vals {
+		if bits[i/64]&(1<<(i%64)) != 0 {
+			vals[i] = mag
+		} else {
+			vals[i] = -mag
+		}
+	}
+	return vals
+}
+
+// cosine estimated from a hamming distance, differing sign bits measure the angle between
+// the vectors as a fraction of pi
+func (ba *binaryArena) cosine(dist int) float64 {
+	return math.Cos(math.Pi * float64(dist) / float64(ba.dim))
+}
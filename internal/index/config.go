@@ -2,13 +2,16 @@ package index
 
 import (
 	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
 	"errors"
 )
 
 type IndexConfig struct {
-	indexType types.IndexType
-	metric    types.SimilarityMetric
-	dimension int
+	indexType    types.IndexType
+	metric       types.SimilarityMetric
+	dimension    int
+	quantization QuantizationConfig
+	precision    types.Precision
 }
 
 // IndexConfig constructor with invariants checks
@@ -21,15 +24,12 @@ func NewIndexConfig(
 		return IndexConfig{}, errors.New("invalid dimension")
 	}
 	switch indexType {
-	case types.LinearIndex, types.HNSWIndex, types.IVFIndex, types.PQIndex:
+	case types.LinearIndex, types.HNSWIndex, types.IVFIndex, types.PQIndex, types.InvertedIndex:
 		//ok valid input
 	default:
 		return IndexConfig{}, errors.New("invalid index type")
 	}
-	switch metric {
-	case types.Cosine, types.Dot, types.Euclidean:
-		//ok valid metrics
-	default:
+	if !vector.IsRegisteredMetric(metric) {
 		return IndexConfig{}, errors.New("invalid metric type")
 	}
 	return IndexConfig{
@@ -45,6 +45,43 @@ func NewIndexConfig(
 func (c IndexConfig) IndexType() types.IndexType     { return c.indexType }
 func (c IndexConfig) Metric() types.SimilarityMetric { return c.metric }
 func (c IndexConfig) Dimension() int                 { return c.dimension }
+func (c IndexConfig) Quantization() QuantizationConfig {
+	return c.quantization
+}
+func (c IndexConfig) Precision() types.Precision { return c.precision }
+
+// WithQuantization returns a copy of the config using the given storage mode, the receiver is left untouched
+func (c IndexConfig) WithQuantization(q QuantizationConfig) (IndexConfig, error) {
+	if err := q.Validate(); err != nil {
+		return IndexConfig{}, err
+	}
+	if q.Type != types.NoQuantization && c.precision != types.Float32Precision {
+		return IndexConfig{}, errors.New("quantization requires float32 precision")
+	}
+	// sign bits only preserve angles, so hamming pre-filtering is only sound for cosine
+	if q.Type == types.BinaryQuantization && c.metric != types.Cosine {
+		return IndexConfig{}, errors.New("binary quantization requires cosine metric")
+	}
+	c.quantization = q
+	return c, nil
+}
+
+// WithPrecision returns a copy of the config storing vectors with the given precision, the receiver is left untouched
+func (c IndexConfig) WithPrecision(p types.Precision) (IndexConfig, error) {
+	switch p {
+	case types.Float32Precision:
+		//ok valid input
+	case types.Float16Precision, types.BFloat16Precision:
+		// quantized indexes already own their compressed layout
+		if c.quantization.Type != types.NoQuantization {
+			return IndexConfig{}, errors.New("quantization requires float32 precision")
+		}
+	default:
+		return IndexConfig{}, errors.New("invalid precision")
+	}
+	c.precision = p
+	return c, nil
+}
 
 // validate config
 func (c IndexConfig) Validate() error {
@@ -57,5 +94,8 @@ func (c IndexConfig) Validate() error {
 	if c.dimension <= 0 {
 		return errors.New("dimenison must be a positive integer")
 	}
+	if err := c.quantization.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
@@ -108,3 +108,74 @@ func TestIndexConfig_InvariantsAndGetters(t *testing.T) {
 		t.Errorf("Invariant broken: Expected metric %v, got %v", expectedMetric, cfg.Metric())
 	}
 }
+
+// Contract: WithQuantization validates the mode and leaves the receiver untouched.
+func TestIndexConfig_WithQuantization(t *testing.T) {
+	base, err := NewIndexConfig(types.LinearIndex, types.Cosine, 8)
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		q           QuantizationConfig
+		expectError bool
+	}{
+		{name: "Success: No Quantization", q: QuantizationConfig{}},
+		{name: "Success: Scalar", q: QuantizationConfig{Type: types.ScalarQuantization}},
+		{name: "Success: Scalar With Rescore", q: QuantizationConfig{Type: types.ScalarQuantization, Rescore: true, Oversample: 8}},
+		{name: "Contract Violation: Unknown Type", q: QuantizationConfig{Type: types.Quantization(42)}, expectError: true},
+		{name: "Contract Violation: Rescore Without Quantization", q: QuantizationConfig{Rescore: true}, expectError: true},
+		{name: "Contract Violation: Negative Oversample", q: QuantizationConfig{Type: types.ScalarQuantization, Oversample: -1}, expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := base.WithQuantization(tt.q)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cfg.Quantization() != tt.q {
+				t.Errorf("Expected quantization %+v, got %+v", tt.q, cfg.Quantization())
+			}
+			if base.Quantization() != (QuantizationConfig{}) {
+				t.Error("Invariant broken: WithQuantization mutated the receiver")
+			}
+		})
+	}
+}
+
+// Contract: binary quantization only accepts the cosine metric.
+func TestIndexConfig_BinaryQuantizationRequiresCosine(t *testing.T) {
+	cfg, _ := NewIndexConfig(types.LinearIndex, types.Euclidean, 4)
+	if _, err := cfg.WithQuantization(QuantizationConfig{Type: types.BinaryQuantization}); err == nil {
+		t.Error("Expected error for binary quantization with euclidean metric")
+	}
+}
+
+// Contract: half precision cannot be combined with quantization, in either order.
+func TestIndexConfig_WithPrecision(t *testing.T) {
+	base, _ := NewIndexConfig(types.LinearIndex, types.Cosine, 4)
+	half, err := base.WithPrecision(types.Float16Precision)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if half.Precision() != types.Float16Precision || base.Precision() != types.Float32Precision {
+		t.Error("Invariant broken: WithPrecision must only change the copy")
+	}
+	if _, err := half.WithQuantization(QuantizationConfig{Type: types.ScalarQuantization}); err == nil {
+		t.Error("Expected error quantizing a half precision config")
+	}
+	quantized, _ := base.WithQuantization(QuantizationConfig{Type: types.ScalarQuantization})
+	if _, err := quantized.WithPrecision(types.BFloat16Precision); err == nil {
+		t.Error("Expected error narrowing a quantized config")
+	}
+	if _, err := base.WithPrecision(types.Precision(7)); err == nil {
+		t.Error("Expected error for unknown precision")
+	}
+}
@@ -1,11 +1,15 @@
 package index
 
 import (
+	"errors"
+
 	"github.com/Kasbe14/Dattaniddhi/internal/types"
 )
 
 type IndexFactory interface {
 	CreateIndex(cfg IndexConfig) (VectorIndex, error)
+	CreateSparseIndex(cfg IndexConfig) (SparseIndex, error)
+	CreateMultiVectorIndex(cfg IndexConfig) (MultiVectorIndex, error)
 }
 
 // empty struct to implement IndexFactory and bind Registery struct and interface
@@ -24,3 +28,24 @@ func (d DefaultIndexFactory) CreateIndex(cfg IndexConfig) (VectorIndex, error) {
 		panic("unsupported index type")
 	}
 }
+
+func (d DefaultIndexFactory) CreateSparseIndex(cfg IndexConfig) (SparseIndex, error) {
+	switch cfg.IndexType() {
+	case types.InvertedIndex:
+		return NewInvertedIndex(cfg)
+	default:
+		return nil, errors.New("index type does not hold sparse vectors")
+	}
+}
+
+// token vectors go into a dense index of cfg's type, MaxSim reranking sits on top of it
+func (d DefaultIndexFactory) CreateMultiVectorIndex(cfg IndexConfig) (MultiVectorIndex, error) {
+	if cfg.IndexType() == types.InvertedIndex {
+		return nil, errors.New("index type does not hold dense token vectors")
+	}
+	tokens, err := d.CreateIndex(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewMaxSimIndex(cfg, tokens)
+}
@@ -0,0 +1,63 @@
+package index
+
+import (
+	"cmp"
+	"slices"
+)
+
+// DefaultRRFConstant is the k of reciprocal rank fusion, 60 as in the original RRF paper
+const DefaultRRFConstant = 60
+
+// FuseRRF merges rankings with reciprocal rank fusion, an id scores Σ 1/(rrfK + rank)
+// over the lists it appears in (rank starts at 1). Only ranks matter, so lists scored
+// on incompatible scales (dense cosine vs sparse dot) can be merged without calibration.
+// Each list must be sorted by descending score, the result is too.
+func FuseRRF(lists [][]SearchResult, rrfK int) []SearchResult {
+	if rrfK <= 0 {
+		rrfK = DefaultRRFConstant
+	}
+	fused := make(map[int]float64)
+	for _, list := range lists {
+		for rank, r := range list {
+			fused[r.VecId] += 1 / float64(rrfK+rank+1)
+		}
+	}
+	return sortFused(fused)
+}
+
+// FuseWeighted merges rankings by min-max normalizing each list's scores to [0,1] and
+// summing them with the given weights, an id missing from a list contributes 0 for it.
+// weights must hold one entry per list. Each list must be sorted by descending score.
+func FuseWeighted(lists [][]SearchResult, weights []float64) []SearchResult {
+	fused := make(map[int]float64)
+	for i, list := range lists {
+		if len(list) == 0 {
+			continue
+		}
+		best, worst := list[0].Score, list[len(list)-1].Score
+		for _, r := range list {
+			norm := 1.0
+			// a list whose scores are all equal carries no ordering, every entry counts fully
+			if best > worst {
+				norm = (r.Score - worst) / (best - worst)
+			}
+			fused[r.VecId] += weights[i] * norm
+		}
+	}
+	return sortFused(fused)
+}
+
+// descending fused score, ties broken by id so results are deterministic
+func sortFused(fused map[int]float64) []SearchResult {
+	result := make([]SearchResult, 0, len(fused))
+	for id, score := range fused {
+		result = append(result, SearchResult{VecId: id, Score: score})
+	}
+	slices.SortFunc(result, func(a, b SearchResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.VecId, b.VecId)
+	})
+	return result
+}
@@ -0,0 +1,46 @@
+package index
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFuseRRF(t *testing.T) {
+	dense := []SearchResult{{VecId: 1, Score: 0.9}, {VecId: 2, Score: 0.8}, {VecId: 3, Score: 0.1}}
+	sparse := []SearchResult{{VecId: 3, Score: 40}, {VecId: 2, Score: 10}}
+
+	fused := FuseRRF([][]SearchResult{dense, sparse}, 0)
+	// 3 (third and first) edges out 2 (second twice): 1/63+1/61 > 2/62, 1 only appears once
+	want := []int{3, 2, 1}
+	for i, id := range want {
+		if fused[i].VecId != id {
+			t.Fatalf("rank %d: expected %d, got %+v", i+1, id, fused)
+		}
+	}
+	if math.Abs(fused[1].Score-2.0/62) > 1e-12 {
+		t.Errorf("expected score 2/62 for id 2, got %v", fused[1].Score)
+	}
+}
+
+func TestFuseWeighted(t *testing.T) {
+	dense := []SearchResult{{VecId: 1, Score: 1}, {VecId: 2, Score: 0.5}, {VecId: 3, Score: 0}}
+	sparse := []SearchResult{{VecId: 3, Score: 100}, {VecId: 1, Score: 0}}
+
+	fused := FuseWeighted([][]SearchResult{dense, sparse}, []float64{0.7, 0.3})
+	// 1: 0.7*1 + 0.3*0 = 0.7, 2: 0.7*0.5 = 0.35, 3: 0 + 0.3*1 = 0.3
+	want := []struct {
+		id    int
+		score float64
+	}{{1, 0.7}, {2, 0.35}, {3, 0.3}}
+	for i, w := range want {
+		if fused[i].VecId != w.id || math.Abs(fused[i].Score-w.score) > 1e-12 {
+			t.Fatalf("rank %d: expected %d/%v, got %+v", i+1, w.id, w.score, fused)
+		}
+	}
+
+	// a list of equal scores counts every entry fully
+	flat := FuseWeighted([][]SearchResult{{{VecId: 5, Score: 2}, {VecId: 4, Score: 2}}}, []float64{1})
+	if flat[0].Score != 1 || flat[1].Score != 1 || flat[0].VecId != 4 {
+		t.Errorf("unexpected flat fusion %+v", flat)
+	}
+}
@@ -0,0 +1,59 @@
+package index
+
+import (
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// float16/bfloat16 replacement of the float32 arena, slot i occupies data[i*dim : (i+1)*dim]
+// halves memory, components are widened to float32 inside the scoring kernels
+type halfArena struct {
+	dim  int
+	prec types.Precision
+	data []uint16
+}
+
+func newHalfArena(dim int, prec types.Precision) *halfArena {
+	return &halfArena{dim: dim, prec: prec}
+}
+
+// appends one empty slot
+func (ha *halfArena) grow() {
+	ha.data = append(ha.data, make([]uint16, ha.dim)...)
+}
+
+func (ha *halfArena) slotData(slot int) []uint16 {
+	return ha.data[slot*ha.dim : (slot+1)*ha.dim : (slot+1)*ha.dim]
+}
+
+func (ha *halfArena) set(slot int, values []float32) {
+	vector.EncodeHalf(values, ha.prec, ha.slotData(slot))
+}
+
+// widened copy of a slot
+func (ha *halfArena) values(slot int) []float32 {
+	vals := make([]float32, ha.dim)
+	vector.DecodeHalf(ha.slotData(slot), ha.prec, vals)
+	return vals
+}
+
+// exact scores of query against the slots first, first+1, ..., one per entry of out
+func (ha *halfArena) scoreRange(query []float32, metric types.SimilarityMetric, first int, out []float32) {
+	switch metric {
+	case types.Cosine, types.Dot, types.InnerProduct:
+		for i := range out {
+			out[i] = vector.DotHalf(query, ha.slotData(first+i), ha.prec)
+		}
+	case types.Euclidean:
+		for i := range out {
+			out[i] = -vector.SquaredEuclideanHalf(query, ha.slotData(first+i), ha.prec)
+		}
+	default:
+		// metrics without a half kernel score the widened slot
+		wide := make([]float32, ha.dim)
+		for i := range out {
+			vector.DecodeHalf(ha.slotData(first+i), ha.prec, wide)
+			scoreArena(query, wide, metric, out[i:i+1])
+		}
+	}
+}
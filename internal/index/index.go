@@ -1,6 +1,8 @@
 package index
 
 import (
+	"context"
+
 	v "github.com/Kasbe14/Dattaniddhi/internal/vector"
 )
 
@@ -13,13 +15,42 @@ type VectorIndex interface {
 	// - Why: During crash recovery, the WAL might replay a delete operation for an item
 	//   that was already cleared from RAM before the crash.
 	// IMPLEMENTATION NOTES:
-	// - LinearIndex: Uses Go's native map delete(), which is naturally a safe no-op if the
-	//   key is missing, meaning it will always return nil.
+	// - LinearIndex: A missing ID has no arena slot, so Delete returns early with nil;
+	//   it always returns nil.
 	// - Future Complex Indexes (e.g., HNSW): If an ID is missing, swallow the condition
 	//   and return nil. A non-nil error must ONLY be returned if the underlying structure
 	//   is physically corrupted (e.g., a broken graph pointer or memory panic).
 	Delete(id int) error
 	Get(id int) (*v.Vector, bool)
 	Search(query *v.Vector, k int) ([]SearchResult, error)
+	// SearchContext is Search bounded by ctx, long scans check it periodically and return
+	// ctx.Err() once it is done
+	SearchContext(ctx context.Context, query *v.Vector, k int) ([]SearchResult, error)
+	Size() int
+}
+
+// BatchSearcher is implemented by indexes that answer many queries faster together than one
+// Search call each, results[i] must equal Search(queries[i], k)
+type BatchSearcher interface {
+	SearchBatch(ctx context.Context, queries []*v.Vector, k int) ([][]SearchResult, error)
+}
+
+// SparseIndex is the VectorIndex contract over sparse vectors, including the
+// idempotent Delete required by WAL recovery. Dimension is the vocabulary size.
+type SparseIndex interface {
+	Add(id int, v *v.SparseVector) (bool, error)
+	Delete(id int) error
+	Get(id int) (*v.SparseVector, bool)
+	Search(query *v.SparseVector, k int) ([]SearchResult, error)
+	Size() int
+}
+
+// MultiVectorIndex is the VectorIndex contract over multi vectors (bags of token vectors),
+// including the idempotent Delete required by WAL recovery. Search scores by MaxSim.
+type MultiVectorIndex interface {
+	Add(id int, v *v.MultiVector) (bool, error)
+	Delete(id int) error
+	Get(id int) (*v.MultiVector, bool)
+	Search(query *v.MultiVector, k int) ([]SearchResult, error)
 	Size() int
 }
@@ -0,0 +1,157 @@
+package index
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	v "github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// one entry of a posting list, the weight a vector carries for the posting's term
+type posting struct {
+	id    int
+	value float32
+}
+
+// InvertedIndex keeps one posting list per vocabulary index over sparse vectors.
+// Search only visits the lists of the query's non zero terms and accumulates sparse
+// dot products, so cost follows the query and posting lengths rather than collection size.
+// Vectors sharing no term with the query score zero and are not returned.
+type InvertedIndex struct {
+	mu     sync.RWMutex
+	config IndexConfig
+	// vocabulary index -> vectors with a non zero value there
+	postings map[uint32][]posting
+	// internal id -> stored vector, needed by Get and to find the postings on Delete
+	vectors map[int]*v.SparseVector
+}
+
+// sparse vectors are only scored by dot product, the dimension is the vocabulary size
+func NewInvertedIndex(cfg IndexConfig) (*InvertedIndex, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to initialize inverted index: %w", err)
+	}
+	switch cfg.Metric() {
+	case types.Cosine, types.Dot, types.InnerProduct:
+		//ok dot product family
+	default:
+		return nil, errors.New("failed to initialize inverted index: only dot product metrics are supported")
+	}
+	if cfg.Quantization().Type != types.NoQuantization || cfg.Precision() != types.Float32Precision {
+		return nil, errors.New("failed to initialize inverted index: quantization and reduced precision are not supported")
+	}
+	return &InvertedIndex{
+		config:   cfg,
+		postings: make(map[uint32][]posting),
+		vectors:  make(map[int]*v.SparseVector),
+	}, nil
+}
+
+func (ii *InvertedIndex) Dimension() int {
+	ii.mu.RLock()
+	defer ii.mu.RUnlock()
+	return ii.config.Dimension()
+}
+
+// Returns false if vector already exist else error
+func (ii *InvertedIndex) Add(id int, vec *v.SparseVector) (bool, error) {
+	ii.mu.Lock()
+	defer ii.mu.Unlock()
+	if id < 0 {
+		return false, errors.New("invalid ID")
+	}
+	if vec == nil {
+		return false, errors.New("empty vector")
+	}
+	if ii.config.Dimension() != vec.Dimensions() {
+		return false, errors.New("vocabulary size mismatch")
+	}
+	if _, ok := ii.vectors[id]; ok {
+		return false, nil
+	}
+	values := vec.Values()
+	for i, term := range vec.Indices() {
+		ii.postings[term] = append(ii.postings[term], posting{id: id, value: values[i]})
+	}
+	ii.vectors[id] = vec
+	return true, nil
+}
+
+func (ii *InvertedIndex) Delete(id int) error {
+	ii.mu.Lock()
+	defer ii.mu.Unlock()
+	//delete is idempotent, missing id is a no-op
+	vec, ok := ii.vectors[id]
+	if !ok {
+		return nil
+	}
+	for _, term := range vec.Indices() {
+		list := slices.DeleteFunc(ii.postings[term], func(p posting) bool { return p.id == id })
+		if len(list) == 0 {
+			delete(ii.postings, term)
+			continue
+		}
+		ii.postings[term] = list
+	}
+	delete(ii.vectors, id)
+	return nil
+}
+
+// sparse vectors are immutable so the stored one is handed back as is
+func (ii *InvertedIndex) Get(id int) (*v.SparseVector, bool) {
+	ii.mu.RLock()
+	defer ii.mu.RUnlock()
+	vec, ok := ii.vectors[id]
+	return vec, ok
+}
+
+func (ii *InvertedIndex) Search(query *v.SparseVector, k int) ([]SearchResult, error) {
+	ii.mu.RLock()
+	defer ii.mu.RUnlock()
+	if len(ii.vectors) == 0 {
+		return nil, nil
+	}
+	if query == nil {
+		return nil, errors.New("empty query input")
+	}
+	if ii.config.Dimension() != query.Dimensions() {
+		return nil, errors.New("index and query vocabulary size mismatched")
+	}
+	if k <= 0 {
+		return nil, errors.New("invalid input for number of results")
+	}
+	scores := make(map[int]float32)
+	qValues := query.Values()
+	for i, term := range query.Indices() {
+		for _, p := range ii.postings[term] {
+			scores[p.id] += qValues[i] * p.value
+		}
+	}
+	result := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		result = append(result, SearchResult{VecId: id, Score: float64(score)})
+	}
+	//sort descending similarity score, ties broken by id so results are deterministic
+	slices.SortFunc(result, func(a, b SearchResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.VecId, b.VecId)
+	})
+	if k > len(result) {
+		return result, nil
+	}
+	return result[:k], nil
+}
+
+func (ii *InvertedIndex) Size() int {
+	ii.mu.RLock()
+	defer ii.mu.RUnlock()
+	return len(ii.vectors)
+}
+
+var _ SparseIndex = (*InvertedIndex)(nil)
@@ -0,0 +1,103 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	v "github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+func setupInvertedIndex(t *testing.T) *InvertedIndex {
+	t.Helper()
+	cfg, err := NewIndexConfig(types.InvertedIndex, types.InnerProduct, 100)
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	idx, err := NewInvertedIndex(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	return idx
+}
+
+func sparse(t *testing.T, indices []uint32, values []float32) *v.SparseVector {
+	t.Helper()
+	sv, err := v.NewSparseVector(indices, values, 100)
+	if err != nil {
+		t.Fatalf("Failed to create sparse vector: %v", err)
+	}
+	return sv
+}
+
+func TestNewInvertedIndex_Constructor(t *testing.T) {
+	cfg, _ := NewIndexConfig(types.InvertedIndex, types.Euclidean, 100)
+	if _, err := NewInvertedIndex(cfg); err == nil {
+		t.Error("Expected error for non dot product metric")
+	}
+	cfg, _ = NewIndexConfig(types.InvertedIndex, types.Dot, 100)
+	cfg, _ = cfg.WithPrecision(types.Float16Precision)
+	if _, err := NewInvertedIndex(cfg); err == nil {
+		t.Error("Expected error for half precision")
+	}
+}
+
+func TestInvertedIndex_SearchRanksBySparseDot(t *testing.T) {
+	idx := setupInvertedIndex(t)
+	idx.Add(1, sparse(t, []uint32{1, 2}, []float32{1, 1}))
+	idx.Add(2, sparse(t, []uint32{2, 3}, []float32{3, 1}))
+	idx.Add(3, sparse(t, []uint32{50}, []float32{9}))
+
+	results, err := idx.Search(sparse(t, []uint32{1, 2}, []float32{1, 2}), 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	// id 3 shares no term and must not be returned
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	if results[0].VecId != 2 || results[0].Score != 6 || results[1].VecId != 1 || results[1].Score != 3 {
+		t.Errorf("unexpected ranking %+v", results)
+	}
+}
+
+func TestInvertedIndex_DeleteRemovesPostings(t *testing.T) {
+	idx := setupInvertedIndex(t)
+	idx.Add(1, sparse(t, []uint32{1, 2}, []float32{1, 1}))
+	idx.Add(2, sparse(t, []uint32{2}, []float32{1}))
+
+	if added, _ := idx.Add(1, sparse(t, []uint32{5}, []float32{1})); added {
+		t.Error("duplicate id should not be added")
+	}
+	if err := idx.Delete(1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	// idempotent
+	if err := idx.Delete(1); err != nil {
+		t.Fatalf("second Delete failed: %v", err)
+	}
+	if _, ok := idx.postings[1]; ok {
+		t.Error("empty posting list was not dropped")
+	}
+	if _, ok := idx.Get(1); ok || idx.Size() != 1 {
+		t.Error("deleted vector still visible")
+	}
+	results, _ := idx.Search(sparse(t, []uint32{1, 2}, []float32{1, 1}), 10)
+	if len(results) != 1 || results[0].VecId != 2 {
+		t.Errorf("unexpected results after delete %+v", results)
+	}
+}
+
+func TestInvertedIndex_Search_Contracts(t *testing.T) {
+	idx := setupInvertedIndex(t)
+	if res, err := idx.Search(sparse(t, []uint32{1}, []float32{1}), 1); err != nil || res != nil {
+		t.Errorf("empty index should return nil, nil; got %v, %v", res, err)
+	}
+	idx.Add(1, sparse(t, []uint32{1}, []float32{1}))
+	if _, err := idx.Search(sparse(t, []uint32{1}, []float32{1}), 0); err == nil {
+		t.Error("expected error for k=0")
+	}
+	other, _ := v.NewSparseVector([]uint32{1}, []float32{1}, 50)
+	if _, err := idx.Search(other, 1); err == nil {
+		t.Error("expected error for vocabulary mismatch")
+	}
+}
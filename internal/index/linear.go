@@ -2,6 +2,7 @@ package index
 
 import (
 	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"slices"
@@ -12,13 +13,35 @@ import (
 	v "github.com/Kasbe14/Dattaniddhi/internal/vector"
 )
 
+// marks an arena slot that holds no live vector
+const freeSlot = -1
+
 // Initial index state is empty, no dimension assigned, no lock
 // after first add index state each index gets its own fixed dimension,
 // IndexConfig is now Imutable and index is schema driven not data driven i.e first IndexConfig structure is defined
+//
+// Storage is a columnar arena: every vector lives back to back in one flat []float32,
+// slot i occupies arena[i*dim : (i+1)*dim]. Search is a sequential read over the arena
+// instead of chasing one heap pointer per vector, and the GC sees a single allocation.
+//
+// With scalar quantization the int8 codes in sq are what Search scans. With binary quantization
+// the scan reads only the sign bits in bq, 32x less memory than the float32 arena. Either way the
+// float32 arena is only kept when the config asks for rescoring of the best oversample*k
+// candidates, otherwise it stays nil and Get hands back what the codes approximate.
+// With float16/bfloat16 precision the half arena replaces the float32 arena entirely.
 type LinearIndex struct {
-	mu      sync.RWMutex
-	vectors map[int]*v.Vector
-	config  IndexConfig
+	mu     sync.RWMutex
+	config IndexConfig
+	arena  []float32
+	sq     *scalarArena
+	bq     *binaryArena
+	half   *halfArena
+	// internal id -> arena slot
+	slots map[int]int
+	// arena slot -> internal id, freeSlot for deleted slots
+	ids []int
+	// slots left behind by deletes, reused by the next Add before the arena grows
+	free []int
 }
 
 // Index must know its invariants at birth, IndexConfig enforces invariants
@@ -26,11 +49,21 @@ func NewLinearIndex(cfg IndexConfig) (*LinearIndex, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("failed to initialize linear index: %w", err)
 	}
-	return &LinearIndex{
-		mu:      sync.RWMutex{},
-		vectors: make(map[int]*v.Vector),
-		config:  cfg,
-	}, nil
+	li := &LinearIndex{
+		mu:     sync.RWMutex{},
+		config: cfg,
+		slots:  make(map[int]int),
+	}
+	switch cfg.Quantization().Type {
+	case types.ScalarQuantization:
+		li.sq = newScalarArena(cfg.Dimension())
+	case types.BinaryQuantization:
+		li.bq = newBinaryArena(cfg.Dimension())
+	}
+	if cfg.Precision() != types.Float32Precision {
+		li.half = newHalfArena(cfg.Dimension(), cfg.Precision())
+	}
+	return li, nil
 }
 func (li *LinearIndex) Dimension() int {
 	li.mu.RLock()
@@ -51,103 +84,330 @@ func (li *LinearIndex) Add(id int, vec *v.Vector) (bool, error) {
 	if li.config.Dimension() != vec.Dimensions() {
 		return false, errors.New("dimension mismatch")
 	}
-	_, ok := li.vectors[id]
+	_, ok := li.slots[id]
 	if ok {
 		return false, nil
 	}
-	li.vectors[id] = vec
+	slot := li.allocSlot()
+	if li.keepsFullPrecision() {
+		vec.CopyValues(li.slotValues(slot))
+	}
+	if li.sq != nil {
+		li.sq.set(slot, vec.Values())
+	}
+	if li.bq != nil {
+		li.bq.set(slot, vec.Values())
+	}
+	if li.half != nil {
+		li.half.set(slot, vec.Values())
+	}
+	li.ids[slot] = id
+	li.slots[id] = slot
 	return true, nil
 }
 func (li *LinearIndex) Delete(id int) error {
 	li.mu.Lock()
 	defer li.mu.Unlock()
-	//delete is idempotent,
-	/*_, ok := li.vectors[id]
+	//delete is idempotent, missing id is a no-op
+	slot, ok := li.slots[id]
 	if !ok {
-		return errors.New("vector doesn't exist in index")
-	}*/
-	delete(li.vectors, id)
+		return nil
+	}
+	delete(li.slots, id)
+	li.ids[slot] = freeSlot
+	li.free = append(li.free, slot)
 	return nil
 }
+
+// quantized indexes without rescoring hand back the dequantized approximation
 func (li *LinearIndex) Get(id int) (*v.Vector, bool) {
 	li.mu.RLock()
 	defer li.mu.RUnlock()
-	vec, ok := li.vectors[id]
-	return vec, ok
+	slot, ok := li.slots[id]
+	if !ok {
+		return nil, false
+	}
+	switch {
+	case li.half != nil:
+		return v.FromNormalized(li.half.values(slot)), true
+	case !li.keepsFullPrecision() && li.bq != nil:
+		return v.FromNormalized(li.bq.values(slot)), true
+	case !li.keepsFullPrecision():
+		return v.FromNormalized(li.sq.values(slot)), true
+	}
+	return v.FromNormalized(li.slotValues(slot)), true
 }
 func (li *LinearIndex) Search(query *v.Vector, k int) ([]SearchResult, error) {
+	return li.SearchContext(context.Background(), query, k)
+}
+
+// SearchContext is Search that gives up with ctx.Err() once ctx is done, the scan checks it
+// between arena tiles
+func (li *LinearIndex) SearchContext(ctx context.Context, query *v.Vector, k int) ([]SearchResult, error) {
 	li.mu.RLock()
 	defer li.mu.RUnlock()
-	if li.Size() == 0 {
+	if len(li.slots) == 0 {
 		return nil, nil
 	}
+	if err := li.checkQuery(query, k); err != nil {
+		return nil, err
+	}
+	return li.search(ctx, query.Values(), k)
+}
+
+// SearchBatch answers many queries under one read lock. Unquantized float32 indexes score a
+// block of queries per pass over the arena, each tile of vectors is read from memory once for
+// the whole block instead of once per query. Results match Search query by query
+func (li *LinearIndex) SearchBatch(ctx context.Context, queries []*v.Vector, k int) ([][]SearchResult, error) {
+	li.mu.RLock()
+	defer li.mu.RUnlock()
+	for i, query := range queries {
+		if err := li.checkQuery(query, k); err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
+		}
+	}
+	results := make([][]SearchResult, len(queries))
+	if len(li.slots) == 0 {
+		return results, nil
+	}
+	// quantized and half precision scans have their own layouts, they run query by query
+	if li.sq != nil || li.bq != nil || li.half != nil {
+		for i, query := range queries {
+			res, err := li.search(ctx, query.Values(), k)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = res
+		}
+		return results, nil
+	}
+	for start := 0; start < len(queries); start += batchQueryBlock {
+		block := queries[start:min(start+batchQueryBlock, len(queries))]
+		scores, err := li.scoreBlock(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		for i, query := range block {
+			results[start+i] = li.topK(query.Values(), scores[i], k)
+		}
+	}
+	return results, nil
+}
+
+func (li *LinearIndex) Size() int {
+	li.mu.RLock()
+	defer li.mu.RUnlock()
+	return len(li.slots)
+}
+
+// arena helpers, callers must hold li.mu
+
+// queries scored together per pass over the arena
+const batchQueryBlock = 8
+
+// float32 values of one arena tile, sized to stay in L2 while a query block is scored.
+// Scans check for cancellation once per tile
+const tileFloats = 1 << 15
+
+// slots per arena tile
+func (li *LinearIndex) tileSlots() int {
+	return max(1, tileFloats/li.config.Dimension())
+}
+
+func (li *LinearIndex) checkQuery(query *v.Vector, k int) error {
 	if query == nil {
-		return nil, errors.New("empty query input")
+		return errors.New("empty query input")
 	}
-	if li.Dimension() != query.Dimensions() {
-		return nil, errors.New("index and query dimension mismatched")
+	if li.config.Dimension() != query.Dimensions() {
+		return errors.New("index and query dimension mismatched")
 	}
 	// if li.config.DataType != query.DataType() {
-	// 	return nil, errors.New("index and vector data type mismatch")
+	// 	return errors.New("index and vector data type mismatch")
 	// }
 	// if li.config.Metric != query.Metric() {
-	// 	return nil, errors.New("index and query similarity metric mismatch")
+	// 	return errors.New("index and query similarity metric mismatch")
 	// }
 	if k <= 0 {
-		return nil, errors.New("invalid input for number of results")
+		return errors.New("invalid input for number of results")
 	}
+	return nil
+}
+
+// top k of a validated query against a non empty index
+func (li *LinearIndex) search(ctx context.Context, qVal []float32, k int) ([]SearchResult, error) {
+	if li.bq != nil {
+		return li.searchBinary(ctx, qVal, k)
+	}
+	// batched kernel passes over the arena tile by tile, freed slots are scored and then skipped
+	scores := make([]float32, len(li.ids))
+	dim, tile := li.config.Dimension(), li.tileSlots()
+	for first := 0; first < len(li.ids); first += tile {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		last := min(first+tile, len(li.ids))
+		switch {
+		case li.sq != nil:
+			li.sq.scoreRange(qVal, li.config.Metric(), first, scores[first:last])
+		case li.half != nil:
+			li.half.scoreRange(qVal, li.config.Metric(), first, scores[first:last])
+		default:
+			scoreArena(qVal, li.arena[first*dim:last*dim], li.config.Metric(), scores[first:last])
+		}
+	}
+	return li.topK(qVal, scores, k), nil
+}
+
+// scores of every query of the block against every slot, one pass over the float32 arena
+func (li *LinearIndex) scoreBlock(ctx context.Context, block []*v.Vector) ([][]float32, error) {
+	scores := make([][]float32, len(block))
+	for i := range scores {
+		scores[i] = make([]float32, len(li.ids))
+	}
+	dim, tile := li.config.Dimension(), li.tileSlots()
+	for first := 0; first < len(li.ids); first += tile {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		last := min(first+tile, len(li.ids))
+		data := li.arena[first*dim : last*dim]
+		for i, query := range block {
+			scoreArena(query.Values(), data, li.config.Metric(), scores[i][first:last])
+		}
+	}
+	return scores, nil
+}
+
+// ranks the live slots by their scores, rescoring quantized candidates when configured
+func (li *LinearIndex) topK(qVal []float32, scores []float32, k int) []SearchResult {
 	// for k >= index size might need li.Size() memory capacity
-	result := make([]SearchResult, 0, li.Size())
+	result := make([]SearchResult, 0, len(li.slots))
 	//sort descending similarity score
-	qVal := query.Values()
-	switch li.config.Metric() {
-	case types.Cosine:
-		for key, val := range li.vectors {
-			simScore, err := vector.Cosine(val.Values(), qVal)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, SearchResult{
-				VecId: key,
-				Score: simScore,
-			})
+	for slot, id := range li.ids {
+		if id == freeSlot {
+			continue
 		}
-		// For current design vector values are nomalized during creation so cosine = dot, (might change later)
-	case types.Dot:
-		for key, val := range li.vectors {
-			simScore, err := vector.DotProduct(val.Values(), qVal)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, SearchResult{
-				VecId: key,
-				Score: simScore,
-			})
+		result = append(result, SearchResult{
+			VecId: id,
+			Score: float64(scores[slot]),
+		})
+	}
+	slices.SortFunc(result, func(a, b SearchResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if li.sq != nil && li.config.Quantization().rescores() {
+		result = li.rescore(qVal, result, li.config.Quantization().candidates(k))
+	}
+	if k > len(result) {
+		return result
+	}
+	return result[:k]
+}
+
+// returns a free slot, reusing deleted slots before growing the arena
+func (li *LinearIndex) allocSlot() int {
+	if n := len(li.free); n > 0 {
+		slot := li.free[n-1]
+		li.free = li.free[:n-1]
+		return slot
+	}
+	slot := len(li.ids)
+	li.ids = append(li.ids, freeSlot)
+	if li.keepsFullPrecision() {
+		li.arena = slices.Grow(li.arena, li.config.Dimension())[:len(li.arena)+li.config.Dimension()]
+	}
+	if li.sq != nil {
+		li.sq.grow()
+	}
+	if li.bq != nil {
+		li.bq.grow()
+	}
+	if li.half != nil {
+		li.half.grow()
+	}
+	return slot
+}
+
+// view of the arena backing one slot, not a copy
+func (li *LinearIndex) slotValues(slot int) []float32 {
+	dim := li.config.Dimension()
+	return li.arena[slot*dim : (slot+1)*dim : (slot+1)*dim]
+}
+
+// float32 arena is the storage itself when unquantized and the rescoring source otherwise,
+// half precision indexes never keep it
+func (li *LinearIndex) keepsFullPrecision() bool {
+	if li.half != nil {
+		return false
+	}
+	return (li.sq == nil && li.bq == nil) || li.config.Quantization().rescores()
+}
+
+// hamming scan over the sign bits, followed by exact rescoring of the closest candidates when
+// the float32 arena is kept
+func (li *LinearIndex) searchBinary(ctx context.Context, query []float32, k int) ([]SearchResult, error) {
+	dists := make([]int, len(li.ids))
+	qBits := li.bq.pack(query)
+	tile := li.tileSlots()
+	for first := 0; first < len(li.ids); first += tile {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
-	case types.Euclidean:
-		for key, val := range li.vectors {
-			simScore, err := vector.Euclidean(val.Values(), qVal)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, SearchResult{
-				VecId: key,
-				Score: simScore,
-			})
+		last := min(first+tile, len(li.ids))
+		li.bq.distances(qBits, first, dists[first:last])
+	}
+	candidates := make([]SearchResult, 0, len(li.slots))
+	for slot, id := range li.ids {
+		if id == freeSlot {
+			continue
 		}
+		// fewer differing bits ranks higher
+		candidates = append(candidates, SearchResult{VecId: id, Score: -float64(dists[slot])})
 	}
-	slices.SortFunc(result, func(a, b SearchResult) int {
+	slices.SortFunc(candidates, func(a, b SearchResult) int {
 		return cmp.Compare(b.Score, a.Score)
 	})
-	if k > li.Size() {
+	result := candidates
+	if li.config.Quantization().rescores() {
+		result = li.rescore(query, candidates, li.config.Quantization().candidates(k))
+	} else {
+		result = result[:min(k, len(result))]
+		for i := range result {
+			result[i].Score = li.bq.cosine(int(-result[i].Score))
+		}
+	}
+	if k > len(result) {
 		return result, nil
 	}
 	return result[:k], nil
 }
-func (li *LinearIndex) Size() int {
-	li.mu.RLock()
-	defer li.mu.RUnlock()
-	return len(li.vectors)
+
+// replaces approximate scores of the best n candidates with exact ones and reorders them,
+// candidates must already be sorted by approximate score
+func (li *LinearIndex) rescore(query []float32, candidates []SearchResult, n int) []SearchResult {
+	candidates = candidates[:min(n, len(candidates))]
+	exact := make([]float32, 1)
+	for i := range candidates {
+		scoreArena(query, li.slotValues(li.slots[candidates[i].VecId]), li.config.Metric(), exact)
+		candidates[i].Score = float64(exact[0])
+	}
+	slices.SortFunc(candidates, func(a, b SearchResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return candidates
+}
+
+// exact float32 scores of query against len(out) vectors stored back to back in data
+func scoreArena(query, data []float32, metric types.SimilarityMetric, out []float32) {
+	impl, ok := vector.LookupMetric(metric)
+	if !ok {
+		// config validation only admits registered metrics
+		panic(fmt.Sprintf("index: unregistered similarity metric %d", metric))
+	}
+	vector.ScoreBatch(impl, query, data, out)
 }
 
-var _ VectorIndex = (*LinearIndex)(nil)
+var (
+	_ VectorIndex   = (*LinearIndex)(nil)
+	_ BatchSearcher = (*LinearIndex)(nil)
+)
@@ -1,10 +1,15 @@
 package index
 
 import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
 	"slices"
 	"testing"
 
 	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
 	v "github.com/Kasbe14/Dattaniddhi/internal/vector"
 )
 
@@ -22,8 +27,8 @@ func TestNewLinearIndex_Constructor(t *testing.T) {
 		}
 
 		// Check Invariants
-		if li.vectors == nil {
-			t.Fatal("Vector map was not initialized")
+		if li.slots == nil {
+			t.Fatal("Slot table was not initialized")
 		}
 
 		// Check Getters (Contracts)
@@ -76,7 +81,7 @@ func TestLinearIndex_AddAndGet(t *testing.T) {
 
 		// Verify via Get (RLock path)
 		retrieved, ok := idx.Get(1)
-		if !ok || retrieved != vec {
+		if !ok || !slices.Equal(retrieved.Values(), vec.Values()) {
 			t.Error("Vector was not stored correctly")
 		}
 	})
@@ -124,6 +129,80 @@ func TestLinearIndex_Delete(t *testing.T) {
 	})
 }
 
+// Invariant: Deleted arena slots are reused before the arena grows.
+// Invariant: A reused slot never leaks the previous vector's values.
+func TestLinearIndex_ArenaSlotReuse(t *testing.T) {
+	idx := setupIndex(t, 2)
+	vecA, _ := v.NewVector([]float32{1.0, 0.0}, 2)
+	vecB, _ := v.NewVector([]float32{0.0, 1.0}, 2)
+	vecC, _ := v.NewVector([]float32{1.0, 1.0}, 2)
+
+	idx.Add(1, vecA)
+	idx.Add(2, vecB)
+	arenaLen := len(idx.arena)
+
+	if err := idx.Delete(1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(idx.free) != 1 {
+		t.Fatalf("Expected 1 free slot after delete, got %d", len(idx.free))
+	}
+
+	idx.Add(3, vecC)
+	if len(idx.arena) != arenaLen {
+		t.Errorf("Arena grew from %d to %d instead of reusing the freed slot", arenaLen, len(idx.arena))
+	}
+	if len(idx.free) != 0 {
+		t.Errorf("Expected free list to be drained, got %d slots", len(idx.free))
+	}
+	if idx.Size() != 2 {
+		t.Errorf("Expected size 2, got %d", idx.Size())
+	}
+
+	got, ok := idx.Get(3)
+	if !ok || !slices.Equal(got.Values(), vecC.Values()) {
+		t.Errorf("Reused slot holds wrong values: %v", got)
+	}
+	if _, ok := idx.Get(1); ok {
+		t.Error("Deleted ID is still reachable through its old slot")
+	}
+}
+
+// Post-condition: Search never returns IDs whose slot was freed.
+func TestLinearIndex_Search_SkipsFreedSlots(t *testing.T) {
+	idx := setupPopulatedIndex(t, types.Cosine)
+	query, _ := v.NewVector([]float32{1.0, 0.0}, 2)
+
+	idx.Delete(1)
+	results, err := idx.Search(query, 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results after delete, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.VecId == 1 {
+			t.Error("Search returned a deleted ID")
+		}
+	}
+}
+
+// Post-condition: Get hands out a copy, a later write to the arena slot must not show
+// through a vector Get returned earlier.
+func TestLinearIndex_Get_ReturnsCopy(t *testing.T) {
+	idx := setupIndex(t, 2)
+	vec, _ := v.NewVector([]float32{1.0, 0.0}, 2)
+	idx.Add(1, vec)
+
+	got, _ := idx.Get(1)
+	idx.slotValues(idx.slots[1])[0] = 42
+
+	if got.Values()[0] == 42 {
+		t.Error("Get leaked a view into the arena")
+	}
+}
+
 // Concurrency Test: Ensures no race conditions occur when multiple goroutines
 // read and write at the same time.
 func TestLinearIndex_Concurrency(t *testing.T) {
@@ -335,3 +414,360 @@ func TestLinearIndex_Search_K_Boundaries(t *testing.T) {
 		}
 	})
 }
+
+//=================tests for scalar quantized storage =========
+
+func setupQuantizedIndex(t *testing.T, metric types.SimilarityMetric, q QuantizationConfig) *LinearIndex {
+	t.Helper()
+	cfg, _ := NewIndexConfig(types.LinearIndex, metric, 2)
+	cfg, err := cfg.WithQuantization(q)
+	if err != nil {
+		t.Fatalf("failed to set quantization: %v", err)
+	}
+	idx, err := NewLinearIndex(cfg)
+	if err != nil {
+		t.Fatalf("failed to setup index: %v", err)
+	}
+	vecA, _ := v.NewVector([]float32{1.0, 0.0}, 2)
+	vecB, _ := v.NewVector([]float32{0.0, 1.0}, 2)
+	vecC, _ := v.NewVector([]float32{0.707, 0.707}, 2)
+	idx.Add(1, vecA)
+	idx.Add(2, vecB)
+	idx.Add(3, vecC)
+	return idx
+}
+
+// Invariant: without rescoring only the int8 codes are kept in memory.
+// Post-condition: Search ranks quantized vectors like the float32 index does.
+func TestLinearIndex_ScalarQuantization_Search(t *testing.T) {
+	for _, metric := range []types.SimilarityMetric{types.Cosine, types.Euclidean} {
+		idx := setupQuantizedIndex(t, metric, QuantizationConfig{Type: types.ScalarQuantization})
+		if idx.arena != nil {
+			t.Errorf("metric %v: float32 arena kept without rescoring", metric)
+		}
+		query, _ := v.NewVector([]float32{1.0, 0.0}, 2)
+		results, err := idx.Search(query, 3)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		want := []int{1, 3, 2}
+		for i, id := range want {
+			if results[i].VecId != id {
+				t.Errorf("metric %v rank %d: expected %d, got %d", metric, i+1, id, results[i].VecId)
+			}
+		}
+	}
+}
+
+// Post-condition: rescored results carry the exact float32 scores.
+func TestLinearIndex_ScalarQuantization_Rescore(t *testing.T) {
+	idx := setupQuantizedIndex(t, types.Cosine, QuantizationConfig{Type: types.ScalarQuantization, Rescore: true, Oversample: 2})
+	query, _ := v.NewVector([]float32{1.0, 0.0}, 2)
+	results, err := idx.Search(query, 1)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].VecId != 1 {
+		t.Fatalf("Expected vector 1 on top, got %v", results)
+	}
+	if results[0].Score != 1.0 {
+		t.Errorf("Expected exact rescored score 1.0, got %v", results[0].Score)
+	}
+}
+
+// Post-condition: Get on a quantized index returns the dequantized approximation.
+func TestLinearIndex_ScalarQuantization_Get(t *testing.T) {
+	idx := setupQuantizedIndex(t, types.Cosine, QuantizationConfig{Type: types.ScalarQuantization})
+	got, ok := idx.Get(3)
+	if !ok {
+		t.Fatal("quantized vector not found")
+	}
+	for i, val := range got.Values() {
+		if d := val - 0.70710677; d > 0.01 || d < -0.01 {
+			t.Errorf("component %d dequantized to %v", i, val)
+		}
+	}
+}
+
+//=================tests for binary quantized storage =========
+
+// Invariant: without rescoring only the sign bits are kept in memory.
+// Post-condition: Search ranks by hamming distance and scores with the estimated cosine.
+func TestLinearIndex_BinaryQuantization_Search(t *testing.T) {
+	idx := setupQuantizedIndex(t, types.Cosine, QuantizationConfig{Type: types.BinaryQuantization})
+	if idx.arena != nil {
+		t.Fatal("float32 arena kept without rescoring")
+	}
+	if len(idx.bq.bits) != 3*vector.BinaryWords(2) {
+		t.Fatalf("expected one word of sign bits per vector, got %d words", len(idx.bq.bits))
+	}
+	query, _ := v.NewVector([]float32{1.0, -0.1}, 2)
+	results, err := idx.Search(query, 3)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	// signs of the query match vector 1, differ from vector 3 in one bit and from vector 2 in both
+	want := []SearchResult{{VecId: 1, Score: 1}, {VecId: 3, Score: 0}, {VecId: 2, Score: -1}}
+	for i, w := range want {
+		if results[i].VecId != w.VecId || math.Abs(results[i].Score-w.Score) > 1e-9 {
+			t.Errorf("rank %d: expected %v, got %v", i+1, w, results[i])
+		}
+	}
+	got, _ := idx.Get(2)
+	if vals := got.Values(); vals[0] >= 0 || vals[1] <= 0 {
+		t.Errorf("Get returned %v, expected the signs of vector 2", vals)
+	}
+}
+
+// Post-condition: the hamming pre-filter plus exact rescoring returns exact cosine scores.
+func TestLinearIndex_BinaryQuantization_Rescore(t *testing.T) {
+	idx := setupQuantizedIndex(t, types.Cosine, QuantizationConfig{Type: types.BinaryQuantization, Rescore: true})
+	if len(idx.bq.bits) != 3*vector.BinaryWords(2) {
+		t.Fatalf("expected one word of sign bits per vector, got %d words", len(idx.bq.bits))
+	}
+	query, _ := v.NewVector([]float32{1.0, 0.1}, 2)
+	results, err := idx.Search(query, 2)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].VecId != 1 || results[1].VecId != 3 {
+		t.Errorf("Expected order [1 3], got [%d %d]", results[0].VecId, results[1].VecId)
+	}
+	vecA, _ := idx.Get(1)
+	want, _ := vector.Cosine(vecA.Values(), query.Values())
+	if math.Abs(results[0].Score-want) > 1e-6 {
+		t.Errorf("Expected exact cosine %v, got %v", want, results[0].Score)
+	}
+}
+
+//=================tests for half precision storage =========
+
+// Invariant: half precision indexes keep no float32 arena.
+// Post-condition: Search and Get work on the widened values.
+func TestLinearIndex_HalfPrecision(t *testing.T) {
+	for _, prec := range []types.Precision{types.Float16Precision, types.BFloat16Precision} {
+		cfg, _ := NewIndexConfig(types.LinearIndex, types.Cosine, 2)
+		cfg, err := cfg.WithPrecision(prec)
+		if err != nil {
+			t.Fatalf("precision %v: %v", prec, err)
+		}
+		idx, _ := NewLinearIndex(cfg)
+		vecA, _ := v.NewVector([]float32{1.0, 0.0}, 2)
+		vecC, _ := v.NewVector([]float32{0.707, 0.707}, 2)
+		idx.Add(1, vecA)
+		idx.Add(3, vecC)
+
+		if idx.arena != nil {
+			t.Errorf("precision %v: float32 arena allocated", prec)
+		}
+		if len(idx.half.data) != 4 {
+			t.Errorf("precision %v: expected 4 half components, got %d", prec, len(idx.half.data))
+		}
+		query, _ := v.NewVector([]float32{1.0, 0.0}, 2)
+		results, err := idx.Search(query, 2)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if results[0].VecId != 1 || results[0].Score != 1.0 {
+			t.Errorf("precision %v: expected exact match on top, got %+v", prec, results[0])
+		}
+		got, _ := idx.Get(3)
+		if d := got.Values()[0] - 0.70710677; d > 0.01 || d < -0.01 {
+			t.Errorf("precision %v: widened value %v", prec, got.Values()[0])
+		}
+	}
+}
+
+// Registry metrics: manhattan, hamming and jaccard rank through the same Search path.
+func TestLinearIndex_Search_RegistryMetrics(t *testing.T) {
+	stored := [][]float32{{1, 1, 0, 0}, {1, 0, 1, 0}, {0, 0, 1, 1}}
+	query := []float32{1, 1, 1, 0}
+	// 1 and 2 tie under every metric here, 3 is always the farthest
+	tests := []struct {
+		metric types.SimilarityMetric
+		last   int
+	}{
+		{types.Manhattan, 3},
+		{types.Hamming, 3},
+		{types.Jaccard, 3},
+		{types.InnerProduct, 3},
+	}
+	for _, tt := range tests {
+		for _, prec := range []types.Precision{types.Float32Precision, types.Float16Precision} {
+			cfg, err := NewIndexConfig(types.LinearIndex, tt.metric, 4)
+			if err != nil {
+				t.Fatalf("metric %v: config failed: %v", tt.metric, err)
+			}
+			cfg, _ = cfg.WithPrecision(prec)
+			idx, _ := NewLinearIndex(cfg)
+			for i, vals := range stored {
+				vec, err := v.NewVectorForMetric(vals, 4, tt.metric, prec)
+				if err != nil {
+					t.Fatalf("metric %v: vector failed: %v", tt.metric, err)
+				}
+				idx.Add(i+1, vec)
+			}
+			q, _ := v.NewVectorForMetric(query, 4, tt.metric, types.Float32Precision)
+			results, err := idx.Search(q, 3)
+			if err != nil {
+				t.Fatalf("metric %v: search failed: %v", tt.metric, err)
+			}
+			if results[2].VecId != tt.last || results[0].Score < results[2].Score {
+				t.Errorf("metric %v precision %v: unexpected ranking %+v", tt.metric, prec, results)
+			}
+		}
+	}
+}
+
+//=================tests for batch search =========
+
+// Post-condition: SearchBatch returns exactly what Search returns per query, across arena
+// tiles, freed slots and quantized storage.
+func TestLinearIndex_SearchBatch(t *testing.T) {
+	const dim = 64
+	rng := rand.New(rand.NewPCG(7, 7))
+	randomVector := func() *v.Vector {
+		vals := make([]float32, dim)
+		for i := range vals {
+			vals[i] = rng.Float32()*2 - 1
+		}
+		vec, _ := v.NewVector(vals, dim)
+		return vec
+	}
+	configs := map[string]QuantizationConfig{
+		"float32": {},
+		"scalar":  {Type: types.ScalarQuantization, Rescore: true, Oversample: 2},
+	}
+	for name, q := range configs {
+		t.Run(name, func(t *testing.T) {
+			cfg, _ := NewIndexConfig(types.LinearIndex, types.Euclidean, dim)
+			cfg, _ = cfg.WithQuantization(q)
+			idx, err := NewLinearIndex(cfg)
+			if err != nil {
+				t.Fatalf("failed to setup index: %v", err)
+			}
+			// more vectors than one tile holds
+			for id := range 3 * tileFloats / dim {
+				idx.Add(id, randomVector())
+			}
+			for id := 0; id < 100; id += 3 {
+				idx.Delete(id)
+			}
+			queries := make([]*v.Vector, 2*batchQueryBlock+3)
+			for i := range queries {
+				queries[i] = randomVector()
+			}
+			results, err := idx.SearchBatch(context.Background(), queries, 10)
+			if err != nil {
+				t.Fatalf("SearchBatch failed: %v", err)
+			}
+			if len(results) != len(queries) {
+				t.Fatalf("expected %d result lists, got %d", len(queries), len(results))
+			}
+			for i, query := range queries {
+				want, _ := idx.Search(query, 10)
+				if !slices.Equal(results[i], want) {
+					t.Errorf("query %d: batch %v, single %v", i, results[i], want)
+				}
+			}
+		})
+	}
+}
+
+// Post-condition: one invalid query fails the whole batch before any search runs.
+func TestLinearIndex_SearchBatch_Contracts(t *testing.T) {
+	idx := setupQuantizedIndex(t, types.Cosine, QuantizationConfig{})
+	good, _ := v.NewVector([]float32{1, 0}, 2)
+	bad, _ := v.NewVector([]float32{1, 0, 0}, 3)
+	if _, err := idx.SearchBatch(context.Background(), []*v.Vector{good, bad}, 1); err == nil {
+		t.Error("expected an error for a dimension mismatch")
+	}
+	if _, err := idx.SearchBatch(context.Background(), []*v.Vector{good, nil}, 1); err == nil {
+		t.Error("expected an error for a nil query")
+	}
+	if _, err := idx.SearchBatch(context.Background(), []*v.Vector{good}, 0); err == nil {
+		t.Error("expected an error for k=0")
+	}
+	results, err := idx.SearchBatch(context.Background(), nil, 1)
+	if err != nil || len(results) != 0 {
+		t.Errorf("expected no results for no queries, got %v, %v", results, err)
+	}
+}
+
+//=================tests for cancellation =========
+
+// Post-condition: a done context stops the scan of every storage layout with ctx.Err().
+func TestLinearIndex_SearchContext_Cancelled(t *testing.T) {
+	half, _ := NewIndexConfig(types.LinearIndex, types.Cosine, 2)
+	half, _ = half.WithPrecision(types.Float16Precision)
+	halfIdx, _ := NewLinearIndex(half)
+	vec, _ := v.NewVector([]float32{1, 0}, 2)
+	halfIdx.Add(1, vec)
+	indexes := map[string]*LinearIndex{
+		"float32": setupQuantizedIndex(t, types.Cosine, QuantizationConfig{}),
+		"scalar":  setupQuantizedIndex(t, types.Cosine, QuantizationConfig{Type: types.ScalarQuantization}),
+		"binary":  setupQuantizedIndex(t, types.Cosine, QuantizationConfig{Type: types.BinaryQuantization}),
+		"half":    halfIdx,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for name, idx := range indexes {
+		if _, err := idx.SearchContext(ctx, vec, 1); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+		if _, err := idx.SearchBatch(ctx, []*v.Vector{vec}, 1); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s batch: expected context.Canceled, got %v", name, err)
+		}
+		if res, err := idx.SearchContext(context.Background(), vec, 1); err != nil || len(res) != 1 {
+			t.Errorf("%s: expected a result with a live context, got %v, %v", name, res, err)
+		}
+	}
+}
+
+// context that reports cancellation from its n+1th Err call on, counting the calls
+type cancelAfter struct {
+	context.Context
+	n, calls int
+}
+
+func (c *cancelAfter) Err() error {
+	c.calls++
+	if c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+// Post-condition: a context cancelled while the arena is being scanned stops the scan at the
+// next tile instead of after the last one.
+func TestLinearIndex_SearchContext_CancelledMidScan(t *testing.T) {
+	const dim = 1024
+	idx := setupIndex(t, dim)
+	vals := make([]float32, dim)
+	for id := range 10 * idx.tileSlots() {
+		vals[id%dim] = 1
+		vec, _ := v.NewVector(vals, dim)
+		idx.Add(id, vec)
+		vals[id%dim] = 0
+	}
+	vals[0] = 1
+	query, _ := v.NewVector(vals, dim)
+
+	ctx := &cancelAfter{Context: context.Background(), n: 2}
+	if _, err := idx.SearchContext(ctx, query, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if ctx.calls != 3 {
+		t.Errorf("expected the scan to stop at the third tile, checked ctx %d times", ctx.calls)
+	}
+	ctx = &cancelAfter{Context: context.Background(), n: 2}
+	if _, err := idx.SearchBatch(ctx, []*v.Vector{query, query}, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("batch: expected context.Canceled, got %v", err)
+	}
+	if ctx.calls != 3 {
+		t.Errorf("batch: expected the scan to stop at the third tile, checked ctx %d times", ctx.calls)
+	}
+}
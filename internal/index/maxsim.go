@@ -0,0 +1,174 @@
+package index
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	v "github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// token candidates fetched per query token as a multiple of k
+const defaultTokenCandidates = 4
+
+// MaxSimIndex serves multi vectors on top of any VectorIndex. Every token vector is added to
+// the token index under its own token id, Search runs one token search per query token to
+// collect candidate points (generation) and then scores only those with exact MaxSim (rerank)
+// against the full multi vectors kept here.
+type MaxSimIndex struct {
+	mu     sync.RWMutex
+	config IndexConfig
+	tokens VectorIndex
+	metric v.Metric
+	// point id -> full multi vector, the rerank source
+	points map[int]*v.MultiVector
+	// point id -> token ids in the token index
+	pointTokens map[int][]int
+	// token id -> point id
+	tokenOwner map[int]int
+	// token ids are never reused so a replayed delete can not hit a newer token
+	nextToken int
+}
+
+// tokens must be empty and built from cfg, the dimension is the token dimension
+func NewMaxSimIndex(cfg IndexConfig, tokens VectorIndex) (*MaxSimIndex, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to initialize maxsim index: %w", err)
+	}
+	if tokens == nil || tokens.Size() != 0 {
+		return nil, errors.New("failed to initialize maxsim index: token index must be empty")
+	}
+	impl, ok := v.LookupMetric(cfg.Metric())
+	if !ok {
+		return nil, errors.New("failed to initialize maxsim index: invalid metric type")
+	}
+	return &MaxSimIndex{
+		config:      cfg,
+		tokens:      tokens,
+		metric:      impl,
+		points:      make(map[int]*v.MultiVector),
+		pointTokens: make(map[int][]int),
+		tokenOwner:  make(map[int]int),
+	}, nil
+}
+
+// Returns false if the point already exist else error
+func (mi *MaxSimIndex) Add(id int, mv *v.MultiVector) (bool, error) {
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	if id < 0 {
+		return false, errors.New("invalid ID")
+	}
+	if mv == nil {
+		return false, errors.New("empty vector")
+	}
+	if mi.config.Dimension() != mv.Dimensions() {
+		return false, errors.New("dimension mismatch")
+	}
+	if _, ok := mi.points[id]; ok {
+		return false, nil
+	}
+	tokenIDs := make([]int, 0, mv.Len())
+	for _, tok := range mv.Vectors() {
+		tokenID := mi.nextToken
+		mi.nextToken++
+		if _, err := mi.tokens.Add(tokenID, tok); err != nil {
+			// undo the tokens of this point so the token index never holds orphans
+			for _, added := range tokenIDs {
+				mi.tokens.Delete(added)
+				delete(mi.tokenOwner, added)
+			}
+			return false, err
+		}
+		mi.tokenOwner[tokenID] = id
+		tokenIDs = append(tokenIDs, tokenID)
+	}
+	mi.points[id] = mv
+	mi.pointTokens[id] = tokenIDs
+	return true, nil
+}
+
+func (mi *MaxSimIndex) Delete(id int) error {
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	//delete is idempotent, missing id is a no-op
+	tokenIDs, ok := mi.pointTokens[id]
+	if !ok {
+		return nil
+	}
+	for _, tokenID := range tokenIDs {
+		if err := mi.tokens.Delete(tokenID); err != nil {
+			return err
+		}
+		delete(mi.tokenOwner, tokenID)
+	}
+	delete(mi.pointTokens, id)
+	delete(mi.points, id)
+	return nil
+}
+
+// multi vectors are immutable so the stored one is handed back as is
+func (mi *MaxSimIndex) Get(id int) (*v.MultiVector, bool) {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	mv, ok := mi.points[id]
+	return mv, ok
+}
+
+func (mi *MaxSimIndex) Search(query *v.MultiVector, k int) ([]SearchResult, error) {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	if len(mi.points) == 0 {
+		return nil, nil
+	}
+	if query == nil {
+		return nil, errors.New("empty query input")
+	}
+	if mi.config.Dimension() != query.Dimensions() {
+		return nil, errors.New("index and query dimension mismatched")
+	}
+	if k <= 0 {
+		return nil, errors.New("invalid input for number of results")
+	}
+	// candidate generation, the points owning the nearest tokens of any query token
+	perToken := defaultTokenCandidates * k
+	candidates := make(map[int]struct{})
+	for _, qTok := range query.Vectors() {
+		hits, err := mi.tokens.Search(qTok, perToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range hits {
+			candidates[mi.tokenOwner[hit.VecId]] = struct{}{}
+		}
+	}
+	// exact MaxSim rerank of the candidates
+	result := make([]SearchResult, 0, len(candidates))
+	for id := range candidates {
+		result = append(result, SearchResult{
+			VecId: id,
+			Score: float64(v.MaxSim(query, mi.points[id], mi.metric)),
+		})
+	}
+	//sort descending similarity score, ties broken by id so results are deterministic
+	slices.SortFunc(result, func(a, b SearchResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.VecId, b.VecId)
+	})
+	if k > len(result) {
+		return result, nil
+	}
+	return result[:k], nil
+}
+
+func (mi *MaxSimIndex) Size() int {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+	return len(mi.points)
+}
+
+var _ MultiVectorIndex = (*MaxSimIndex)(nil)
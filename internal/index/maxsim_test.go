@@ -0,0 +1,92 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	v "github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+func setupMaxSimIndex(t *testing.T) *MaxSimIndex {
+	t.Helper()
+	cfg, err := NewIndexConfig(types.LinearIndex, types.InnerProduct, 2)
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	tokens, err := NewLinearIndex(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create token index: %v", err)
+	}
+	idx, err := NewMaxSimIndex(cfg, tokens)
+	if err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	return idx
+}
+
+func multi(t *testing.T, rows ...[]float32) *v.MultiVector {
+	t.Helper()
+	mv, err := v.NewMultiVector(rows, 2, types.InnerProduct, types.Float32Precision)
+	if err != nil {
+		t.Fatalf("Failed to create multi vector: %v", err)
+	}
+	return mv
+}
+
+func TestMaxSimIndex_AddDeleteGet(t *testing.T) {
+	idx := setupMaxSimIndex(t)
+	mv := multi(t, []float32{1, 0}, []float32{0, 1})
+	if added, err := idx.Add(1, mv); err != nil || !added {
+		t.Fatalf("Add failed: %v, %v", added, err)
+	}
+	if added, _ := idx.Add(1, mv); added {
+		t.Error("Expected duplicate id to be rejected")
+	}
+	if got, ok := idx.Get(1); !ok || got != mv {
+		t.Error("Get did not return the stored multi vector")
+	}
+	if idx.Size() != 1 || idx.tokens.Size() != 2 {
+		t.Errorf("expected 1 point with 2 tokens, got %d points %d tokens", idx.Size(), idx.tokens.Size())
+	}
+	if err := idx.Delete(1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := idx.Delete(1); err != nil {
+		t.Errorf("Delete should be idempotent, got %v", err)
+	}
+	if idx.Size() != 0 || idx.tokens.Size() != 0 {
+		t.Errorf("expected empty index after delete, got %d points %d tokens", idx.Size(), idx.tokens.Size())
+	}
+}
+
+func TestMaxSimIndex_Search(t *testing.T) {
+	idx := setupMaxSimIndex(t)
+	// point 1 matches both query tokens, point 2 only the first one but strongly,
+	// point 3 only the second one
+	idx.Add(1, multi(t, []float32{1, 0}, []float32{0, 1}))
+	idx.Add(2, multi(t, []float32{3, 0}))
+	idx.Add(3, multi(t, []float32{0, 1}, []float32{0, 0.5}))
+	query := multi(t, []float32{1, 0}, []float32{0, 1})
+
+	results, err := idx.Search(query, 3)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	// MaxSim: point 2 = 3+0, point 1 = 1+1, point 3 = 0+1
+	want := []SearchResult{{VecId: 2, Score: 3}, {VecId: 1, Score: 2}, {VecId: 3, Score: 1}}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), results)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("rank %d: expected %+v, got %+v", i, want[i], results[i])
+		}
+	}
+	results, _ = idx.Search(query, 1)
+	if len(results) != 1 || results[0].VecId != 2 {
+		t.Errorf("expected only point 2, got %+v", results)
+	}
+	if _, err := idx.Search(query, 0); err == nil {
+		t.Error("Expected error for k = 0")
+	}
+}
@@ -0,0 +1,52 @@
+package index
+
+import (
+	"errors"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+// candidates rescored per requested result when Oversample is left at zero
+const DefaultOversample = 4
+
+// QuantizationConfig describes the compressed in-memory storage of an index
+// zero value is plain float32 storage
+type QuantizationConfig struct {
+	Type types.Quantization
+	// keep the float32 originals next to the codes and re-rank the best approximate candidates exactly.
+	// Without it only the codes are held in memory: int8 codes are a quarter of the float32 arena,
+	// binary sign bits a 32nd, and binary scores are then cosines estimated from hamming distances
+	Rescore bool
+	// candidates rescored per requested result, 0 means DefaultOversample
+	Oversample int
+}
+
+func (q QuantizationConfig) Validate() error {
+	switch q.Type {
+	case types.NoQuantization:
+		if q.Rescore || q.Oversample != 0 {
+			return errors.New("rescoring requires a quantized index")
+		}
+	case types.ScalarQuantization, types.BinaryQuantization:
+		//ok valid input
+	default:
+		return errors.New("invalid quantization type")
+	}
+	if q.Oversample < 0 {
+		return errors.New("oversample must not be negative")
+	}
+	return nil
+}
+
+// whether the float32 originals are kept for exact rescoring
+func (q QuantizationConfig) rescores() bool {
+	return q.Rescore
+}
+
+// number of approximate candidates to rescore for k results
+func (q QuantizationConfig) candidates(k int) int {
+	if q.Oversample == 0 {
+		return k * DefaultOversample
+	}
+	return k * q.Oversample
+}
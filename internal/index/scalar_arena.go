@@ -0,0 +1,93 @@
+package index
+
+import (
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
+)
+
+// per-slot quantization parameters
+type scalarParams struct {
+	offset float32
+	scale  float32
+	// squared norm of the dequantized vector, needed for approximate euclidean scores
+	sqNorm float32
+}
+
+// int8 twin of the float32 arena, slot i occupies codes[i*dim : (i+1)*dim]
+// usable by any index that addresses vectors by slot
+type scalarArena struct {
+	dim    int
+	codes  []int8
+	params []scalarParams
+}
+
+func newScalarArena(dim int) *scalarArena {
+	return &scalarArena{dim: dim}
+}
+
+// appends one empty slot
+func (sa *scalarArena) grow() {
+	sa.codes = append(sa.codes, make([]int8, sa.dim)...)
+	sa.params = append(sa.params, scalarParams{})
+}
+
+func (sa *scalarArena) slotCodes(slot int) []int8 {
+	return sa.codes[slot*sa.dim : (slot+1)*sa.dim : (slot+1)*sa.dim]
+}
+
+// quantizes values into slot
+func (sa *scalarArena) set(slot int, values []float32) {
+	codes := sa.slotCodes(slot)
+	offset, scale := vector.QuantizeInt8(values, codes)
+	deq := make([]float32, sa.dim)
+	vector.DequantizeInt8(codes, offset, scale, deq)
+	sa.params[slot] = scalarParams{
+		offset: offset,
+		scale:  scale,
+		sqNorm: vector.DotFloat32(deq, deq),
+	}
+}
+
+// dequantized copy of a slot
+func (sa *scalarArena) values(slot int) []float32 {
+	p := sa.params[slot]
+	vals := make([]float32, sa.dim)
+	vector.DequantizeInt8(sa.slotCodes(slot), p.offset, p.scale, vals)
+	return vals
+}
+
+// approximate scores of query against the slots first, first+1, ..., one per entry of out
+func (sa *scalarArena) scoreRange(query []float32, metric types.SimilarityMetric, first int, out []float32) {
+	switch metric {
+	case types.Cosine, types.Dot, types.InnerProduct, types.Euclidean:
+		sa.scoreRangeDot(query, metric, first, out)
+		return
+	}
+	// metrics without an int8 kernel score the dequantized slot
+	deq := make([]float32, sa.dim)
+	for i := range out {
+		p := sa.params[first+i]
+		vector.DequantizeInt8(sa.slotCodes(first+i), p.offset, p.scale, deq)
+		scoreArena(query, deq, metric, out[i:i+1])
+	}
+}
+
+// int8 dot product path, euclidean is derived from the dot product and the stored norms
+func (sa *scalarArena) scoreRangeDot(query []float32, metric types.SimilarityMetric, first int, out []float32) {
+	var qSum float32
+	for _, q := range query {
+		qSum += q
+	}
+	qNorm := vector.DotFloat32(query, query)
+	for i := range out {
+		p := sa.params[first+i]
+		dot := vector.ScalarDot(query, qSum, sa.slotCodes(first+i), p.offset, p.scale)
+		switch metric {
+		case types.Euclidean:
+			// ||q-x||² = ||q||² - 2q·x + ||x||², negated like the exact score
+			out[i] = -(qNorm - 2*dot + p.sqNorm)
+		default:
+			out[i] = dot
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+)
+
+// FileStore is a log structured payload file. Puts and deletes are appended as records,
+// an in-memory map from id to record location is rebuilt by scanning the file on open and
+// payload bytes are only read (and JSON decoded) by Get, so large payloads stay on disk.
+// Superseded records are garbage until Compact rewrites the live ones into a new file.
+// Appends are flushed as the collection's WAL sync policy asks, a Put of the bytes already
+// stored for an id appends nothing, so WAL replay on open does not grow the file.
+//
+// record layout, little endian:
+// 4 -> crc32 (IEEE) of everything after it
+// 1 -> op, recordPut or recordDelete
+// 2 -> id length, 4 -> value length
+// id bytes, value bytes (the JSON payload, empty for deletes)
+type FileStore struct {
+	mu   sync.RWMutex
+	path string
+	file *os.File
+	// end of the last valid record, where the next one is appended
+	size int64
+	// id -> location of its live put record
+	entries map[string]entry
+	// bytes taken by superseded and delete records
+	garbage    int64
+	decode     DecodeFunc
+	syncPolicy wal.SyncPolicy
+	// last fsync of the file, drives wal.SyncEverySec
+	lastSync time.Time
+}
+
+// DecodeFunc turns the stored JSON of a payload back into the value Get returns
+type DecodeFunc func(raw []byte) (any, error)
+
+type entry struct {
+	// offset of the value bytes in the file
+	offset int64
+	length uint32
+	// whole record size, counted as garbage once superseded
+	recordSize int64
+	// checksum of the record, a Put with the same one and the same bytes is a no-op
+	crc uint32
+}
+
+const (
+	recordPut    uint8 = 1
+	recordDelete uint8 = 2
+	// crc + op + id length + value length
+	recordHeaderSize = 4 + 1 + 2 + 4
+	// compaction only runs once this much garbage piled up and outweighs the live records
+	compactMinGarbage = 4 * 1024 * 1024
+)
+
+// OpenFileStore opens or creates the payload file at path. A torn or corrupt tail, e.g. from a
+// crash mid append, is cut off, the WAL replay puts back whatever it held. Get decodes with
+// decode, nil decodes generic JSON (numbers as float64). Appends are synced like a WAL with
+// policy sync: every append for SyncAlways, at most once a second for SyncEverySec
+func OpenFileStore(path string, decode DecodeFunc, sync wal.SyncPolicy) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create payload store directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open payload store %s: %w", path, err)
+	}
+	fs := &FileStore{
+		path:       path,
+		file:       file,
+		entries:    make(map[string]entry),
+		decode:     decode,
+		syncPolicy: sync,
+		lastSync:   time.Now(),
+	}
+	if err := fs.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return fs, nil
+}
+
+// scans every record and rebuilds the entry map, truncates at the first invalid one
+func (fs *FileStore) load() error {
+	info, err := fs.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat payload store: %w", err)
+	}
+	fileSize := info.Size()
+	header := make([]byte, recordHeaderSize)
+	var offset int64
+	for offset+recordHeaderSize <= fileSize {
+		if _, err := fs.file.ReadAt(header, offset); err != nil {
+			return fmt.Errorf("failed to read payload store: %w", err)
+		}
+		op := header[4]
+		idLen := int64(binary.LittleEndian.Uint16(header[5:7]))
+		valLen := int64(binary.LittleEndian.Uint32(header[7:11]))
+		recordSize := recordHeaderSize + idLen + valLen
+		if (op != recordPut && op != recordDelete) || offset+recordSize > fileSize {
+			break
+		}
+		body := make([]byte, recordSize-4)
+		if _, err := fs.file.ReadAt(body, offset+4); err != nil {
+			return fmt.Errorf("failed to read payload store: %w", err)
+		}
+		if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(header[0:4]) {
+			break
+		}
+		id := string(body[recordHeaderSize-4 : recordHeaderSize-4+idLen])
+		fs.apply(op, id, entry{offset: offset + recordHeaderSize + idLen, length: uint32(valLen), recordSize: recordSize, crc: binary.LittleEndian.Uint32(header[0:4])})
+		offset += recordSize
+	}
+	if offset != fileSize {
+		if err := fs.file.Truncate(offset); err != nil {
+			return fmt.Errorf("failed to truncate corrupt payload store tail: %w", err)
+		}
+	}
+	fs.size = offset
+	return nil
+}
+
+// updates the entry map and garbage count for one record
+func (fs *FileStore) apply(op uint8, id string, e entry) {
+	if old, ok := fs.entries[id]; ok {
+		fs.garbage += old.recordSize
+		delete(fs.entries, id)
+	}
+	if op == recordDelete {
+		fs.garbage += e.recordSize
+		return
+	}
+	fs.entries[id] = e
+}
+
+func encodeRecord(op uint8, id string, value []byte) []byte {
+	buf := make([]byte, recordHeaderSize+len(id)+len(value))
+	buf[4] = op
+	binary.LittleEndian.PutUint16(buf[5:7], uint16(len(id)))
+	binary.LittleEndian.PutUint32(buf[7:11], uint32(len(value)))
+	copy(buf[recordHeaderSize:], id)
+	copy(buf[recordHeaderSize+len(id):], value)
+	binary.LittleEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[4:]))
+	return buf
+}
+
+// appends one record at the end of the valid data, caller holds fs.mu
+func (fs *FileStore) append(op uint8, id string, value []byte) error {
+	if fs.file == nil {
+		return ErrClosed
+	}
+	if len(id) > 0xFFFF {
+		return fmt.Errorf("payload id too long: %d bytes", len(id))
+	}
+	buf := encodeRecord(op, id, value)
+	crc := binary.LittleEndian.Uint32(buf[0:4])
+	if op == recordPut {
+		unchanged, err := fs.holds(id, value, crc)
+		if err != nil || unchanged {
+			return err
+		}
+	}
+	if _, err := fs.file.WriteAt(buf, fs.size); err != nil {
+		return fmt.Errorf("failed to write payload store: %w", err)
+	}
+	idLen := int64(len(id))
+	fs.apply(op, id, entry{offset: fs.size + recordHeaderSize + idLen, length: uint32(len(value)), recordSize: int64(len(buf)), crc: crc})
+	fs.size += int64(len(buf))
+	if err := fs.syncAppend(); err != nil {
+		return err
+	}
+	if fs.garbage > compactMinGarbage && fs.garbage > fs.size-fs.garbage {
+		return fs.compact()
+	}
+	return nil
+}
+
+// whether the live record of id already stores value, crc is the checksum of its put record.
+// The bytes are only read back when length and checksum match, caller holds fs.mu
+func (fs *FileStore) holds(id string, value []byte, crc uint32) (bool, error) {
+	e, ok := fs.entries[id]
+	if !ok || e.crc != crc || int(e.length) != len(value) {
+		return false, nil
+	}
+	stored := make([]byte, e.length)
+	if _, err := fs.file.ReadAt(stored, e.offset); err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read payload %s: %w", id, err)
+	}
+	return bytes.Equal(stored, value), nil
+}
+
+// flushes appended records as the sync policy asks, SyncEverySec has no background goroutine,
+// the first append a second after the last sync flushes and Close flushes the rest.
+// caller holds fs.mu
+func (fs *FileStore) syncAppend() error {
+	switch fs.syncPolicy {
+	case wal.SyncAlways:
+	case wal.SyncEverySec:
+		if time.Since(fs.lastSync) < time.Second {
+			return nil
+		}
+	default:
+		// SyncOS leaves it to the os
+		return nil
+	}
+	if err := fs.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync payload store: %w", err)
+	}
+	fs.lastSync = time.Now()
+	return nil
+}
+
+// only the raw JSON is kept, payload is ignored
+func (fs *FileStore) Put(id string, payload any, raw []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.append(recordPut, id, raw)
+}
+
+// reads and decodes the payload, without a DecodeFunc an empty value decodes to nil like a JSON null
+func (fs *FileStore) Get(id string) (any, bool, error) {
+	raw, ok, err := fs.GetRaw(id)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if fs.decode != nil {
+		payload, err := fs.decode(raw)
+		if err != nil {
+			return nil, true, fmt.Errorf("corrupt payload %s: %w", id, err)
+		}
+		return payload, true, nil
+	}
+	var payload any
+	if len(raw) == 0 {
+		return nil, true, nil
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, true, fmt.Errorf("corrupt payload %s: %w", id, err)
+	}
+	return payload, true, nil
+}
+
+// GetRaw returns the stored JSON bytes of id without decoding them
+func (fs *FileStore) GetRaw(id string) ([]byte, bool, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if fs.file == nil {
+		return nil, false, ErrClosed
+	}
+	e, ok := fs.entries[id]
+	if !ok {
+		return nil, false, nil
+	}
+	raw := make([]byte, e.length)
+	if _, err := fs.file.ReadAt(raw, e.offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, true, fmt.Errorf("failed to read payload %s: %w", id, err)
+	}
+	return raw, true, nil
+}
+
+func (fs *FileStore) Has(id string) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	_, ok := fs.entries[id]
+	return ok
+}
+
+func (fs *FileStore) Delete(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.entries[id]; !ok {
+		return nil
+	}
+	return fs.append(recordDelete, id, nil)
+}
+
+func (fs *FileStore) Keys() []string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	keys := make([]string, 0, len(fs.entries))
+	for id := range fs.entries {
+		keys = append(keys, id)
+	}
+	return keys
+}
+
+func (fs *FileStore) Len() int {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return len(fs.entries)
+}
+
+// Compact rewrites the live records into a fresh file and swaps it in, dropping all garbage
+func (fs *FileStore) Compact() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.file == nil {
+		return ErrClosed
+	}
+	return fs.compact()
+}
+
+// caller holds fs.mu
+func (fs *FileStore) compact() error {
+	tmpPath := fs.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted payload store: %w", err)
+	}
+	entries := make(map[string]entry, len(fs.entries))
+	var offset int64
+	for id, e := range fs.entries {
+		raw := make([]byte, e.length)
+		if _, err := fs.file.ReadAt(raw, e.offset); err != nil && !errors.Is(err, io.EOF) {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to read payload %s during compaction: %w", id, err)
+		}
+		buf := encodeRecord(recordPut, id, raw)
+		if _, err := tmp.WriteAt(buf, offset); err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write compacted payload store: %w", err)
+		}
+		entries[id] = entry{offset: offset + recordHeaderSize + int64(len(id)), length: e.length, recordSize: int64(len(buf)), crc: e.crc}
+		offset += int64(len(buf))
+	}
+	// the new file must be durable before it replaces the old one
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync compacted payload store: %w", err)
+	}
+	if err := os.Rename(tmpPath, fs.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to swap compacted payload store: %w", err)
+	}
+	fs.file.Close()
+	fs.file = tmp
+	fs.entries = entries
+	fs.size = offset
+	fs.garbage = 0
+	// the rename itself only survives a crash once the directory entry is synced
+	if err := syncDir(filepath.Dir(fs.path)); err != nil {
+		return fmt.Errorf("failed to sync payload store directory: %w", err)
+	}
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	syncErr := d.Sync()
+	closeErr := d.Close()
+	if syncErr != nil {
+		return syncErr
+	}
+	return closeErr
+}
+
+// Close flushes the file, the store can not be used afterwards
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.file == nil {
+		return nil
+	}
+	syncErr := fs.file.Sync()
+	closeErr := fs.file.Close()
+	fs.file = nil
+	if syncErr != nil {
+		return syncErr
+	}
+	return closeErr
+}
+
+var _ PayloadStore = (*FileStore)(nil)
@@ -0,0 +1,144 @@
+package payload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/store/wal"
+)
+
+func openTestStore(t *testing.T, path string) *FileStore {
+	t.Helper()
+	fs, err := OpenFileStore(path, nil, wal.SyncAlways)
+	if err != nil {
+		t.Fatalf("OpenFileStore failed: %v", err)
+	}
+	return fs
+}
+
+func TestFileStore_PutGetDelete(t *testing.T) {
+	fs := openTestStore(t, filepath.Join(t.TempDir(), "payloads.log"))
+	defer fs.Close()
+
+	if err := fs.Put("a", nil, []byte(`{"name":"alpha","n":1}`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	// payloads without JSON data decode to nil
+	if err := fs.Put("b", nil, nil); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	got, ok, err := fs.Get("a")
+	if err != nil || !ok {
+		t.Fatalf("Get failed: %v, %v", ok, err)
+	}
+	if !reflect.DeepEqual(got, map[string]any{"name": "alpha", "n": float64(1)}) {
+		t.Errorf("unexpected payload %v", got)
+	}
+	if got, ok, _ := fs.Get("b"); !ok || got != nil {
+		t.Errorf("expected nil payload for b, got %v, %v", got, ok)
+	}
+	if err := fs.Put("a", nil, []byte(`"replaced"`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if got, _, _ := fs.Get("a"); got != "replaced" {
+		t.Errorf("expected replaced payload, got %v", got)
+	}
+	if err := fs.Delete("a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := fs.Delete("a"); err != nil {
+		t.Errorf("Delete should be idempotent, got %v", err)
+	}
+	if fs.Has("a") || fs.Len() != 1 {
+		t.Errorf("expected only b left, got keys %v", fs.Keys())
+	}
+}
+
+func TestFileStore_ReopenAndTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payloads.log")
+	fs := openTestStore(t, path)
+	fs.Put("a", nil, []byte(`"alpha"`))
+	fs.Put("b", nil, []byte(`"beta"`))
+	fs.Delete("a")
+	fs.Put("c", nil, []byte(`"gamma"`))
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, _, err := fs.Get("b"); err != ErrClosed {
+		t.Errorf("expected ErrClosed after Close, got %v", err)
+	}
+
+	// a crash mid append leaves half a record behind
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatalf("truncate failed: %v", err)
+	}
+	fs = openTestStore(t, path)
+	defer fs.Close()
+	if fs.Has("a") || fs.Has("c") {
+		t.Errorf("expected a deleted and torn c dropped, got keys %v", fs.Keys())
+	}
+	if got, ok, _ := fs.Get("b"); !ok || got != "beta" {
+		t.Errorf("expected beta to survive reopen, got %v, %v", got, ok)
+	}
+	// appends continue after the cut, not after the garbage
+	fs.Put("d", nil, []byte(`"delta"`))
+	if got, _, _ := fs.Get("d"); got != "delta" {
+		t.Errorf("expected delta, got %v", got)
+	}
+}
+
+// Post-condition: putting the bytes already stored appends nothing, also after a reopen,
+// while a changed payload of the same length is still written.
+func TestFileStore_UnchangedPutIsNoOp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payloads.log")
+	fs := openTestStore(t, path)
+	fs.Put("a", nil, []byte(`"alpha"`))
+	before, _ := os.Stat(path)
+	fs.Put("a", nil, []byte(`"alpha"`))
+	fs.Close()
+
+	fs = openTestStore(t, path)
+	defer fs.Close()
+	fs.Put("a", nil, []byte(`"alpha"`))
+	after, _ := os.Stat(path)
+	if after.Size() != before.Size() || fs.garbage != 0 {
+		t.Errorf("unchanged puts grew the file %d -> %d bytes, %d bytes garbage", before.Size(), after.Size(), fs.garbage)
+	}
+	fs.Put("a", nil, []byte(`"omega"`))
+	if got, _, _ := fs.Get("a"); got != "omega" {
+		t.Errorf("expected the changed payload, got %v", got)
+	}
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payloads.log")
+	fs := openTestStore(t, path)
+	for i := range 10 {
+		fs.Put("a", nil, fmt.Appendf(nil, `{"big":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa","v":%d}`, i))
+	}
+	fs.Put("b", nil, []byte(`"beta"`))
+	fs.Put("gone", nil, []byte(`"x"`))
+	fs.Delete("gone")
+	before, _ := os.Stat(path)
+	if err := fs.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Errorf("expected compaction to shrink the file, %d -> %d bytes", before.Size(), after.Size())
+	}
+	if got, _, _ := fs.Get("b"); got != "beta" || fs.Len() != 2 {
+		t.Errorf("unexpected state after compaction, b = %v, keys %v", got, fs.Keys())
+	}
+	fs.Close()
+
+	fs = openTestStore(t, path)
+	defer fs.Close()
+	if fs.Len() != 2 || fs.garbage != 0 {
+		t.Errorf("expected 2 live records and no garbage after reopen, got %v and %d bytes", fs.Keys(), fs.garbage)
+	}
+}
@@ -0,0 +1,64 @@
+package payload
+
+import "sync"
+
+// MemoryStore keeps payloads as the values handed to Put, Get returns them as is
+type MemoryStore struct {
+	mu       sync.RWMutex
+	payloads map[string]any
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{payloads: make(map[string]any)}
+}
+
+func (ms *MemoryStore) Put(id string, payload any, raw []byte) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.payloads[id] = payload
+	return nil
+}
+
+func (ms *MemoryStore) Get(id string) (any, bool, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	payload, ok := ms.payloads[id]
+	return payload, ok, nil
+}
+
+func (ms *MemoryStore) Has(id string) bool {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	_, ok := ms.payloads[id]
+	return ok
+}
+
+func (ms *MemoryStore) Delete(id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.payloads, id)
+	return nil
+}
+
+func (ms *MemoryStore) Keys() []string {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	keys := make([]string, 0, len(ms.payloads))
+	for id := range ms.payloads {
+		keys = append(keys, id)
+	}
+	return keys
+}
+
+func (ms *MemoryStore) Len() int {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return len(ms.payloads)
+}
+
+// nothing to release, the map is dropped with the store
+func (ms *MemoryStore) Close() error {
+	return nil
+}
+
+var _ PayloadStore = (*MemoryStore)(nil)
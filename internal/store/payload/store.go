@@ -0,0 +1,24 @@
+package payload
+
+import "errors"
+
+// ErrClosed is returned by stores used after Close
+var ErrClosed = errors.New("payload store closed")
+
+// PayloadStore keeps the payload of every live point by external id. The WAL stays the
+// source of truth, a store only has to hold what was put since it was opened plus whatever
+// it persisted before, recovery re-puts anything missing and deletes what the WAL deleted.
+type PayloadStore interface {
+	// Put stores the payload of id, raw is its JSON encoding as logged in the WAL.
+	// Stores keep whichever form suits them, a later Put replaces the earlier one
+	Put(id string, payload any, raw []byte) error
+	// Get returns the payload of id, stores holding only raw bytes decode them on each call
+	Get(id string) (any, bool, error)
+	Has(id string) bool
+	// Delete is idempotent, missing ids are a no-op
+	Delete(id string) error
+	// ids of every stored payload in no particular order
+	Keys() []string
+	Len() int
+	Close() error
+}
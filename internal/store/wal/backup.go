@@ -0,0 +1,75 @@
+package wal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CopySegments calls fn for every segment holding records up to and including lsn, in segment
+// order, with the segment's file name, the number of bytes to copy and a reader of them. Each
+// copy is cut after the last record with a sequence number <= lsn, so records appended
+// meanwhile are left out and the copies replay to the state as of lsn. Safe to call while
+// appends continue, the bytes before the cut are never rewritten
+func (w *WAL) CopySegments(lsn uint64, fn func(name string, size int64, r io.Reader) error) error {
+	segments, err := w.getAllSegment()
+	if err != nil {
+		return fmt.Errorf("failed to get segments from WAL directory: %w", err)
+	}
+	for _, segment := range segments {
+		file, err := os.OpenFile(segment.path, os.O_RDONLY, 0444)
+		if err != nil {
+			return fmt.Errorf("failed to open the segment file %d: %w", segment.segID, err)
+		}
+		cut, records, err := segmentCut(file, lsn)
+		if err == nil && records > 0 {
+			err = fn(filepath.Base(segment.path), cut, io.NewSectionReader(file, 0, cut))
+		}
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to copy segment %d: %w", segment.segID, err)
+		}
+		if records == 0 {
+			// later segments only hold newer records
+			return nil
+		}
+	}
+	return nil
+}
+
+// returns the offset just after the last complete record with a sequence number <= lsn and the
+// number of such records, only the record headers are read
+func segmentCut(file *os.File, lsn uint64) (int64, int, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	size := info.Size()
+	header := make([]byte, segmentHeaderByteSize)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return 0, 0, fmt.Errorf("failed to read segment header: %w", err)
+	}
+	if _, err := decodeSegmentHeader(header); err != nil {
+		return 0, 0, err
+	}
+	offset := int64(segmentHeaderByteSize)
+	records := 0
+	recordHeader := make([]byte, recordHeaderByteSize)
+	for offset+recordHeaderByteSize <= size {
+		if _, err := file.ReadAt(recordHeader, offset); err != nil {
+			return 0, 0, fmt.Errorf("failed to read record header: %w", err)
+		}
+		rh, err := decodeRecordHeader(recordHeader)
+		if err != nil {
+			return 0, 0, err
+		}
+		// a torn or still growing record ends the copy like it ends recovery
+		if rh.lsn > lsn || rh.recordLength < minRecordLength || offset+int64(rh.recordLength) > size {
+			break
+		}
+		offset += int64(rh.recordLength)
+		records++
+	}
+	return offset, records, nil
+}
@@ -0,0 +1,62 @@
+package wal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWAL_CopySegments(t *testing.T) {
+	w, err := NewWAL(t.TempDir(), SyncOS)
+	if err != nil {
+		t.Fatalf("NewWAL failed: %v", err)
+	}
+	defer w.Close()
+	for i := uint64(1); i <= 3; i++ {
+		if _, err := w.AppendInsert("ext", i, []float32{1, 2}, []byte(`{}`)); err != nil {
+			t.Fatalf("AppendInsert failed: %v", err)
+		}
+	}
+	if _, err := w.AppendDelete("ext", 1); err != nil {
+		t.Fatalf("AppendDelete failed: %v", err)
+	}
+
+	// the copy cut at lsn 2 replays to the first two records only
+	copyDir := t.TempDir()
+	var names []string
+	err = w.CopySegments(2, func(name string, size int64, r io.Reader) error {
+		names = append(names, name)
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) != size {
+			t.Errorf("segment %s: expected %d bytes, read %d", name, size, len(data))
+		}
+		return os.WriteFile(filepath.Join(copyDir, name), data, 0644)
+	})
+	if err != nil {
+		t.Fatalf("CopySegments failed: %v", err)
+	}
+	if len(names) != 1 || names[0] != "0000000001.waldrky" {
+		t.Fatalf("expected the first segment copied, got %v", names)
+	}
+	restored := &WAL{dir: copyDir}
+	records, err := restored.Recover()
+	if err != nil {
+		t.Fatalf("Recover of the copy failed: %v", err)
+	}
+	if len(records) != 2 || records[0].LSN != 1 || records[1].LSN != 2 {
+		t.Errorf("expected records 1 and 2, got %+v", records)
+	}
+
+	// nothing is copied before the first record
+	calls := 0
+	if err := w.CopySegments(0, func(string, int64, io.Reader) error { calls++; return nil }); err != nil {
+		t.Fatalf("CopySegments failed: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no segment copied at lsn 0, got %d", calls)
+	}
+}
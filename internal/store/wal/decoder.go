@@ -5,6 +5,9 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
 )
 
 func decodeSegmentHeader(shBytes []byte) (*segmentHeader, error) {
@@ -17,7 +20,7 @@ func decodeSegmentHeader(shBytes []byte) (*segmentHeader, error) {
 	}
 	//wal version chek
 	version := shBytes[7]
-	if version != walVersion {
+	if version < minWALVersion || version > walVersion {
 		return nil, fmt.Errorf("corrupted segment: invalid wal version")
 	}
 	segID := binary.LittleEndian.Uint64(shBytes[8:])
@@ -47,7 +50,7 @@ func decodeRecordHeader(rhBytes []byte) (*recordHeader, error) {
 		//pading skipped 24-32
 	}
 	//version check
-	if rh.version != walVersion {
+	if rh.version < minWALVersion || rh.version > walVersion {
 		return nil, fmt.Errorf("unsupported wal version: %d", rh.version)
 	}
 	//optype check
@@ -68,7 +71,7 @@ func decodeRecordHeader(rhBytes []byte) (*recordHeader, error) {
 
 func isValidOptype(op uint8) bool {
 	switch op {
-	case OpInsert, OpDelete, OpUpdate:
+	case OpInsert, OpDelete, OpUpdate, OpInsertSparse:
 		//ok
 		return true
 	default:
@@ -76,7 +79,8 @@ func isValidOptype(op uint8) bool {
 	}
 }
 
-func decodeInsertPayload(plBytes []byte) (*insertPayload, error) {
+// version is the record header version, it selects the payload layout
+func decodeInsertPayload(plBytes []byte, version uint8) (*insertPayload, error) {
 
 	//read size of the external id string
 	offset := 0
@@ -98,23 +102,29 @@ func decodeInsertPayload(plBytes []byte) (*insertPayload, error) {
 	}
 	intID := binary.LittleEndian.Uint64(plBytes[offset:])
 	offset += 8
+	//version 1 records predate half precision and are always float32
+	precision := types.Float32Precision
+	if version >= 2 {
+		if offset+1 > len(plBytes) {
+			return nil, fmt.Errorf("corrupted payload: incomplete vector precision")
+		}
+		precision = types.Precision(plBytes[offset])
+		offset += 1
+		if !validPrecision(precision) {
+			return nil, fmt.Errorf("corrupted payload: invalid vector precision %d", precision)
+		}
+	}
 	//read vector size
 	if offset+4 > len(plBytes) {
 		return nil, fmt.Errorf("corrupted payload: incomplete vector size")
 	}
 	vectorDimension := binary.LittleEndian.Uint32(plBytes[offset:])
 	offset += 4
-	// create and read vector values
-	vectorData := make([]float32, vectorDimension)
-	var i uint32
-	for i = 0; i < (vectorDimension); i++ {
-		//converting uint32 bit to float32
-		if offset+4 > len(plBytes) {
-			return nil, fmt.Errorf("corrupted payload : incomplete vector values")
-		}
-		vectorData[i] = math.Float32frombits(binary.LittleEndian.Uint32(plBytes[offset:]))
-		offset += 4
+	vectorData, n, err := decodeComponents(plBytes[offset:], vectorDimension, precision)
+	if err != nil {
+		return nil, err
 	}
+	offset += n
 	if offset+4 > len(plBytes) {
 		return nil, fmt.Errorf("corrupted payload: incorrect meta data size")
 	}
@@ -127,12 +137,264 @@ func decodeInsertPayload(plBytes []byte) (*insertPayload, error) {
 	}
 	copy(metaDataBytes, plBytes[offset:offset+int(metaDataSize)])
 	offset += int(metaDataSize)
-	return &insertPayload{
+	ip := &insertPayload{
 		externalID: extID,
 		internalID: intID,
 		vectorData: vectorData,
+		precision:  precision,
 		metaData:   metaDataBytes,
-	}, nil
+	}
+	//version 4 appends the sparse part of hybrid points
+	if version >= 4 {
+		indices, values, n, err := decodeSparsePairs(plBytes[offset:])
+		if err != nil {
+			return nil, err
+		}
+		offset += n
+		if len(indices) > 0 {
+			ip.sparseIndices = indices
+			ip.sparseValues = values
+		}
+	}
+	//version 5 appends the vectors of named fields
+	if version >= 5 {
+		named, n, err := decodeNamedVectors(plBytes[offset:])
+		if err != nil {
+			return nil, err
+		}
+		offset += n
+		ip.namedVectors = named
+	}
+	//version 6 appends the token vectors of multi vector fields
+	if version >= 6 {
+		multi, n, err := decodeMultiVectors(plBytes[offset:])
+		if err != nil {
+			return nil, err
+		}
+		offset += n
+		ip.multiVectors = multi
+	}
+	//version 8 appends the expiry time
+	if version >= 8 {
+		expiresAt, err := decodeExpiry(plBytes[offset:])
+		if err != nil {
+			return nil, err
+		}
+		ip.expiresAt = expiresAt
+	}
+	return ip, nil
+}
+
+func validPrecision(prec types.Precision) bool {
+	switch prec {
+	case types.Float32Precision, types.Float16Precision, types.BFloat16Precision:
+		return true
+	default:
+		return false
+	}
+}
+
+// reads dim components stored at prec, half precision values are widened, returns the bytes consumed
+func decodeComponents(plBytes []byte, dim uint32, prec types.Precision) ([]float32, int, error) {
+	// bound the allocation by what the payload can actually hold before trusting the dimension
+	width := componentSize(prec)
+	if uint64(dim)*uint64(width) > uint64(len(plBytes)) {
+		return nil, 0, fmt.Errorf("corrupted payload : incomplete vector values")
+	}
+	values := make([]float32, dim)
+	offset := 0
+	for i := range values {
+		if prec == types.Float32Precision {
+			//converting uint32 bit to float32
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(plBytes[offset:]))
+		} else {
+			//widening half bits to float32
+			values[i] = widenHalf(binary.LittleEndian.Uint16(plBytes[offset:]), prec)
+		}
+		offset += width
+	}
+	return values, offset, nil
+}
+
+// reads the named vector section of a version 5 insert payload, nil when it is empty,
+// returns the bytes consumed
+func decodeNamedVectors(plBytes []byte) ([]NamedVector, int, error) {
+	offset := 0
+	if offset+2 > len(plBytes) {
+		return nil, 0, fmt.Errorf("corrupted payload: incomplete named vector count")
+	}
+	count := int(binary.LittleEndian.Uint16(plBytes[offset:]))
+	offset += 2
+	var named []NamedVector
+	for range count {
+		if offset+2 > len(plBytes) {
+			return nil, 0, fmt.Errorf("corrupted payload: incomplete named vector name length")
+		}
+		nameLen := int(binary.LittleEndian.Uint16(plBytes[offset:]))
+		offset += 2
+		if offset+nameLen+1+4 > len(plBytes) {
+			return nil, 0, fmt.Errorf("corrupted payload: incomplete named vector header")
+		}
+		name := string(bytes.Clone(plBytes[offset : offset+nameLen]))
+		offset += nameLen
+		prec := types.Precision(plBytes[offset])
+		offset += 1
+		if !validPrecision(prec) {
+			return nil, 0, fmt.Errorf("corrupted payload: invalid precision %d for named vector %s", prec, name)
+		}
+		dim := binary.LittleEndian.Uint32(plBytes[offset:])
+		offset += 4
+		values, n, err := decodeComponents(plBytes[offset:], dim, prec)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += n
+		named = append(named, NamedVector{Name: name, Vector: values, Precision: prec})
+	}
+	return named, offset, nil
+}
+
+// reads the multi vector section of a version 6 insert payload, nil when it is empty,
+// returns the bytes consumed
+func decodeMultiVectors(plBytes []byte) ([]NamedMultiVector, int, error) {
+	offset := 0
+	if offset+2 > len(plBytes) {
+		return nil, 0, fmt.Errorf("corrupted payload: incomplete multi vector count")
+	}
+	count := int(binary.LittleEndian.Uint16(plBytes[offset:]))
+	offset += 2
+	var multi []NamedMultiVector
+	for range count {
+		if offset+2 > len(plBytes) {
+			return nil, 0, fmt.Errorf("corrupted payload: incomplete multi vector name length")
+		}
+		nameLen := int(binary.LittleEndian.Uint16(plBytes[offset:]))
+		offset += 2
+		if offset+nameLen+1+4+4 > len(plBytes) {
+			return nil, 0, fmt.Errorf("corrupted payload: incomplete multi vector header")
+		}
+		name := string(bytes.Clone(plBytes[offset : offset+nameLen]))
+		offset += nameLen
+		prec := types.Precision(plBytes[offset])
+		offset += 1
+		if !validPrecision(prec) {
+			return nil, 0, fmt.Errorf("corrupted payload: invalid precision %d for multi vector %s", prec, name)
+		}
+		dim := binary.LittleEndian.Uint32(plBytes[offset:])
+		offset += 4
+		rows := binary.LittleEndian.Uint32(plBytes[offset:])
+		offset += 4
+		// stored bags are never empty, and with dim at least 1 the size check below bounds
+		// rows by the remaining bytes before the row slice is allocated
+		if dim == 0 || rows == 0 {
+			return nil, 0, fmt.Errorf("corrupted payload: empty multi vector %s", name)
+		}
+		if uint64(rows)*uint64(dim)*uint64(componentSize(prec)) > uint64(len(plBytes)-offset) {
+			return nil, 0, fmt.Errorf("corrupted payload: incomplete multi vector values")
+		}
+		vectors := make([][]float32, rows)
+		for i := range vectors {
+			values, n, err := decodeComponents(plBytes[offset:], dim, prec)
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += n
+			vectors[i] = values
+		}
+		multi = append(multi, NamedMultiVector{Name: name, Vectors: vectors, Precision: prec})
+	}
+	return multi, offset, nil
+}
+
+// reads the expiry time of a version 8 insert payload
+func decodeExpiry(plBytes []byte) (int64, error) {
+	if len(plBytes) < 8 {
+		return 0, fmt.Errorf("corrupted payload: incomplete expiry time")
+	}
+	return int64(binary.LittleEndian.Uint64(plBytes)), nil
+}
+
+func widenHalf(h uint16, prec types.Precision) float32 {
+	if prec == types.BFloat16Precision {
+		return vector.BFloat16ToFloat32(h)
+	}
+	return vector.Float16ToFloat32(h)
+}
+
+// version is the record header version, it selects the payload layout
+func decodeSparseInsertPayload(plBytes []byte, version uint8) (*sparseInsertPayload, error) {
+	offset := 0
+	if len(plBytes) < 2 {
+		return nil, fmt.Errorf("corrupted payload: incomplete external id length")
+	}
+	extIDLen := binary.LittleEndian.Uint16(plBytes)
+	offset += 2
+	if offset+int(extIDLen) > len(plBytes) {
+		return nil, fmt.Errorf("corrupted payload: incomplete external id")
+	}
+	extID := string(bytes.Clone(plBytes[offset : offset+int(extIDLen)]))
+	offset += int(extIDLen)
+	if offset+8 > len(plBytes) {
+		return nil, fmt.Errorf("corrupted payload: incomplete internal id")
+	}
+	intID := binary.LittleEndian.Uint64(plBytes[offset:])
+	offset += 8
+	indices, values, n, err := decodeSparsePairs(plBytes[offset:])
+	if err != nil {
+		return nil, err
+	}
+	offset += n
+	if offset+4 > len(plBytes) {
+		return nil, fmt.Errorf("corrupted payload: incorrect meta data size")
+	}
+	metaDataSize := binary.LittleEndian.Uint32(plBytes[offset:])
+	offset += 4
+	if uint64(offset)+uint64(metaDataSize) > uint64(len(plBytes)) {
+		return nil, fmt.Errorf("corrupted payload: incomplete metadata")
+	}
+	metaDataBytes := bytes.Clone(plBytes[offset : offset+int(metaDataSize)])
+	offset += int(metaDataSize)
+	sp := &sparseInsertPayload{
+		externalID: extID,
+		internalID: intID,
+		indices:    indices,
+		values:     values,
+		metaData:   metaDataBytes,
+	}
+	//version 8 appends the expiry time
+	if version >= 8 {
+		expiresAt, err := decodeExpiry(plBytes[offset:])
+		if err != nil {
+			return nil, err
+		}
+		sp.expiresAt = expiresAt
+	}
+	return sp, nil
+}
+
+// reads a sparse pair section written by encodeSparsePairs, returns the bytes consumed
+func decodeSparsePairs(plBytes []byte) ([]uint32, []float32, int, error) {
+	offset := 0
+	if offset+4 > len(plBytes) {
+		return nil, nil, 0, fmt.Errorf("corrupted payload: incomplete sparse vector size")
+	}
+	nnz := binary.LittleEndian.Uint32(plBytes[offset:])
+	offset += 4
+	// bound the allocation by what the payload can actually hold before trusting nnz
+	if uint64(offset)+uint64(nnz)*8 > uint64(len(plBytes)) {
+		return nil, nil, 0, fmt.Errorf("corrupted payload: incomplete sparse vector pairs")
+	}
+	indices := make([]uint32, nnz)
+	for i := range indices {
+		indices[i] = binary.LittleEndian.Uint32(plBytes[offset:])
+		offset += 4
+	}
+	values := make([]float32, nnz)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(plBytes[offset:]))
+		offset += 4
+	}
+	return indices, values, offset, nil
 }
 
 func decodeDeletePayload(plBytes []byte) (*deletePayload, error) {
@@ -170,5 +432,46 @@ func decodeCheckSumCrc32(chBytes []byte) (uint32, error) {
 	return checksum, nil
 }
 
-//TODO : update Operation and format
-//func decodeUpdatePayload(plByte []byte) (*updatePayload, error)
+func decodeUpdatePayload(plBytes []byte) (*updatePayload, error) {
+	offset := 0
+	if len(plBytes) < 2 {
+		return nil, fmt.Errorf("corrupted payload: incomplete external id length")
+	}
+	extIDLen := int(binary.LittleEndian.Uint16(plBytes))
+	offset += 2
+	if offset+extIDLen > len(plBytes) {
+		return nil, fmt.Errorf("corrupted payload: incomplete external id")
+	}
+	extID := string(bytes.Clone(plBytes[offset : offset+extIDLen]))
+	offset += extIDLen
+	if offset+8+1+4 > len(plBytes) {
+		return nil, fmt.Errorf("corrupted payload: incomplete update header")
+	}
+	intID := binary.LittleEndian.Uint64(plBytes[offset:])
+	offset += 8
+	kind := plBytes[offset]
+	offset += 1
+	if !validUpdateKind(kind) {
+		return nil, fmt.Errorf("corrupted payload: invalid update kind %d", kind)
+	}
+	dataLen := int(binary.LittleEndian.Uint32(plBytes[offset:]))
+	offset += 4
+	if offset+dataLen > len(plBytes) {
+		return nil, fmt.Errorf("corrupted payload: incomplete update data")
+	}
+	return &updatePayload{
+		externalID: extID,
+		internalID: intID,
+		kind:       kind,
+		data:       bytes.Clone(plBytes[offset : offset+dataLen]),
+	}, nil
+}
+
+func validUpdateKind(kind uint8) bool {
+	switch kind {
+	case UpdateSetKeys, UpdateDeleteKeys, UpdateOverwrite:
+		return true
+	default:
+		return false
+	}
+}
@@ -2,8 +2,13 @@ package wal
 
 import (
 	"bytes"
+	"encoding/binary"
+	"math"
 	"reflect"
 	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
 )
 
 // -----------------------------------------------------------------------------
@@ -77,7 +82,7 @@ func TestInsertPayloadDecoder(t *testing.T) {
 	encodedBytes := original.encode()
 
 	t.Run("Success: Valid Insert Payload", func(t *testing.T) {
-		decoded, err := decodeInsertPayload(encodedBytes)
+		decoded, err := decodeInsertPayload(encodedBytes, walVersion)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -107,7 +112,7 @@ func TestInsertPayloadDecoder(t *testing.T) {
 		}
 
 		for _, truncateLen := range truncations {
-			_, err := decodeInsertPayload(encodedBytes[:truncateLen])
+			_, err := decodeInsertPayload(encodedBytes[:truncateLen], walVersion)
 			if err == nil {
 				t.Errorf("Expected error when payload is truncated to %d bytes", truncateLen)
 			}
@@ -115,6 +120,247 @@ func TestInsertPayloadDecoder(t *testing.T) {
 	})
 }
 
+// -----------------------------------------------------------------------------
+// Test: Sparse Insert Payload Decoder
+// -----------------------------------------------------------------------------
+func TestSparseInsertPayloadDecoder(t *testing.T) {
+	original := &sparseInsertPayload{
+		externalID: "doc-sparse",
+		internalID: 7,
+		indices:    []uint32{3, 17, 40000},
+		values:     []float32{0.5, 1.25, -2},
+		metaData:   []byte(`{"lang":"en"}`),
+	}
+	encodedBytes := original.encode()
+	if uint32(len(encodedBytes)) != original.size() {
+		t.Fatalf("encoded %d bytes, size() reports %d", len(encodedBytes), original.size())
+	}
+
+	t.Run("Success: Valid Sparse Payload", func(t *testing.T) {
+		decoded, err := decodeSparseInsertPayload(encodedBytes, walVersion)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if decoded.externalID != original.externalID || decoded.internalID != original.internalID {
+			t.Errorf("ids mismatch: got %s/%d", decoded.externalID, decoded.internalID)
+		}
+		if !reflect.DeepEqual(decoded.indices, original.indices) || !reflect.DeepEqual(decoded.values, original.values) {
+			t.Errorf("pairs mismatch: got %v %v", decoded.indices, decoded.values)
+		}
+		if !reflect.DeepEqual(decoded.metaData, original.metaData) {
+			t.Errorf("Metadata mismatch: got %s", decoded.metaData)
+		}
+	})
+
+	t.Run("Failure: Truncated Bytes (Bounds Checking)", func(t *testing.T) {
+		for truncateLen := 0; truncateLen < len(encodedBytes); truncateLen++ {
+			if _, err := decodeSparseInsertPayload(encodedBytes[:truncateLen], walVersion); err == nil {
+				t.Errorf("Expected error when payload is truncated to %d bytes", truncateLen)
+			}
+		}
+	})
+}
+
+// -----------------------------------------------------------------------------
+// Test: Hybrid insert payloads (walVersion 4) carry a trailing sparse section
+// -----------------------------------------------------------------------------
+func TestInsertPayloadDecoder_HybridSparseSection(t *testing.T) {
+	original := &insertPayload{
+		externalID:    "doc-hybrid",
+		internalID:    12,
+		vectorData:    []float32{0.6, 0.8},
+		metaData:      []byte(`{}`),
+		sparseIndices: []uint32{4, 900},
+		sparseValues:  []float32{1.5, 0.25},
+	}
+	encodedBytes := original.encode()
+	decoded, err := decodeInsertPayload(encodedBytes, walVersion)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.vectorData, original.vectorData) {
+		t.Errorf("Vector data mismatch: got %v", decoded.vectorData)
+	}
+	if !reflect.DeepEqual(decoded.sparseIndices, original.sparseIndices) || !reflect.DeepEqual(decoded.sparseValues, original.sparseValues) {
+		t.Errorf("Sparse section mismatch: got %v %v", decoded.sparseIndices, decoded.sparseValues)
+	}
+	// a version 4 payload cut inside the sparse section is corrupt
+	if _, err := decodeInsertPayload(encodedBytes[:len(encodedBytes)-3], walVersion); err == nil {
+		t.Error("Expected error for truncated sparse section")
+	}
+	// dense only payloads decode without a sparse part
+	dense := &insertPayload{externalID: "d", internalID: 1, vectorData: []float32{1}}
+	decoded, err = decodeInsertPayload(dense.encode(), walVersion)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.sparseIndices != nil {
+		t.Errorf("Expected no sparse part, got %v", decoded.sparseIndices)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Test: Named vector fields (walVersion 5) follow the sparse section
+// -----------------------------------------------------------------------------
+func TestInsertPayloadDecoder_NamedVectors(t *testing.T) {
+	original := &insertPayload{
+		externalID: "doc-named",
+		internalID: 3,
+		vectorData: []float32{1, 0},
+		namedVectors: []NamedVector{
+			{Name: "body", Vector: []float32{0.25, 0.5, -1}, Precision: types.Float32Precision},
+			{Name: "image", Vector: []float32{0.5, -0.25}, Precision: types.Float16Precision},
+		},
+	}
+	encodedBytes := original.encode()
+	if uint32(len(encodedBytes)) != original.size() {
+		t.Fatalf("encoded %d bytes, size() reports %d", len(encodedBytes), original.size())
+	}
+	decoded, err := decodeInsertPayload(encodedBytes, walVersion)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.namedVectors, original.namedVectors) {
+		t.Errorf("Named vectors mismatch.\nExpected: %+v\nGot:      %+v", original.namedVectors, decoded.namedVectors)
+	}
+	for cut := 1; cut <= 10; cut++ {
+		if _, err := decodeInsertPayload(encodedBytes[:len(encodedBytes)-cut], walVersion); err == nil {
+			t.Errorf("Expected error when %d trailing bytes are missing", cut)
+		}
+	}
+	// a version 4 reader stops before the named section
+	decoded, err = decodeInsertPayload(encodedBytes, 4)
+	if err != nil || decoded.namedVectors != nil {
+		t.Errorf("version 4 decode should ignore named vectors, got %v, %v", decoded, err)
+	}
+}
+
+func TestInsertPayloadDecoder_MultiVectors(t *testing.T) {
+	original := &insertPayload{
+		externalID: "doc-multi",
+		internalID: 4,
+		vectorData: []float32{1, 0},
+		multiVectors: []NamedMultiVector{
+			{Name: "tokens", Vectors: [][]float32{{1, 0, 0}, {0, 0.5, 0.5}, {-1, 0, 0.25}}, Precision: types.Float32Precision},
+			{Name: "patches", Vectors: [][]float32{{0.5, -0.25}}, Precision: types.BFloat16Precision},
+		},
+	}
+	encodedBytes := original.encode()
+	if uint32(len(encodedBytes)) != original.size() {
+		t.Fatalf("encoded %d bytes, size() reports %d", len(encodedBytes), original.size())
+	}
+	decoded, err := decodeInsertPayload(encodedBytes, walVersion)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.multiVectors, original.multiVectors) {
+		t.Errorf("Multi vectors mismatch.\nExpected: %+v\nGot:      %+v", original.multiVectors, decoded.multiVectors)
+	}
+	for cut := 1; cut <= 12; cut++ {
+		if _, err := decodeInsertPayload(encodedBytes[:len(encodedBytes)-cut], walVersion); err == nil {
+			t.Errorf("Expected error when %d trailing bytes are missing", cut)
+		}
+	}
+	// a zero dimension must not let a huge row count through the size check
+	crafted := []byte{1, 0, 1, 0, 'a', byte(types.Float32Precision), 0, 0, 0, 0, 0xff, 0xff, 0xff, 0x7f}
+	if _, _, err := decodeMultiVectors(crafted); err == nil {
+		t.Error("Expected error for a multi vector with dimension 0")
+	}
+	crafted = []byte{1, 0, 1, 0, 'a', byte(types.Float32Precision), 4, 0, 0, 0, 0, 0, 0, 0}
+	if _, _, err := decodeMultiVectors(crafted); err == nil {
+		t.Error("Expected error for a multi vector without rows")
+	}
+	// a version 5 reader stops before the multi vector section
+	decoded, err = decodeInsertPayload(encodedBytes, 5)
+	if err != nil || decoded.multiVectors != nil {
+		t.Errorf("version 5 decode should ignore multi vectors, got %v, %v", decoded, err)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Test: Half precision insert payloads (walVersion 2)
+// -----------------------------------------------------------------------------
+func TestInsertPayloadDecoder_HalfPrecision(t *testing.T) {
+	values := []float32{0.5, -0.25, 0.1, 1}
+	for _, prec := range []types.Precision{types.Float16Precision, types.BFloat16Precision} {
+		original := &insertPayload{
+			externalID: "doc-half",
+			internalID: 7,
+			vectorData: values,
+			precision:  prec,
+			metaData:   []byte(`{}`),
+		}
+		encodedBytes := original.encode()
+		if uint32(len(encodedBytes)) != original.size() {
+			t.Fatalf("precision %v: encoded %d bytes, size() says %d", prec, len(encodedBytes), original.size())
+		}
+
+		decoded, err := decodeInsertPayload(encodedBytes, walVersion)
+		if err != nil {
+			t.Fatalf("precision %v: unexpected error: %v", prec, err)
+		}
+		if decoded.precision != prec {
+			t.Errorf("Expected precision %v, got %v", prec, decoded.precision)
+		}
+		want := make([]float32, len(values))
+		copy(want, values)
+		vector.RoundToPrecision(want, prec)
+		if !reflect.DeepEqual(decoded.vectorData, want) {
+			t.Errorf("precision %v: expected %v, got %v", prec, want, decoded.vectorData)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Test: walVersion 1 insert payloads (no precision byte) still decode
+// -----------------------------------------------------------------------------
+func TestInsertPayloadDecoder_LegacyVersion1(t *testing.T) {
+	extID := "doc-v1"
+	meta := []byte(`{"v":1}`)
+	values := []float32{1.5, -2.5}
+	buf := binary.LittleEndian.AppendUint16(nil, uint16(len(extID)))
+	buf = append(buf, extID...)
+	buf = binary.LittleEndian.AppendUint64(buf, 11)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(values)))
+	for _, f := range values {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(f))
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(meta)))
+	buf = append(buf, meta...)
+
+	decoded, err := decodeInsertPayload(buf, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.externalID != extID || decoded.internalID != 11 {
+		t.Errorf("Header fields mismatch: %s %d", decoded.externalID, decoded.internalID)
+	}
+	if decoded.precision != types.Float32Precision {
+		t.Errorf("Expected float32 precision for version 1, got %v", decoded.precision)
+	}
+	if !reflect.DeepEqual(decoded.vectorData, values) || !reflect.DeepEqual(decoded.metaData, meta) {
+		t.Errorf("Payload mismatch: %v %s", decoded.vectorData, decoded.metaData)
+	}
+
+	// a version 1 record header is still accepted
+	rh := newRecordHeader(1, 5, OpInsert)
+	rh.recordLength = uint32(recordHeaderByteSize + len(buf) + 4)
+	if _, err := decodeRecordHeader(encodeRecordHeader(*rh)); err != nil {
+		t.Errorf("Version 1 record header rejected: %v", err)
+	}
+}
+
+// Contract: an unknown precision byte is corruption, not a silent float32 read.
+func TestInsertPayloadDecoder_InvalidPrecision(t *testing.T) {
+	original := &insertPayload{externalID: "x", internalID: 1, vectorData: []float32{1}}
+	encodedBytes := original.encode()
+	// precision byte follows the 2 byte id length, the id and the 8 byte internal id
+	encodedBytes[2+1+8] = 9
+	if _, err := decodeInsertPayload(encodedBytes, walVersion); err == nil {
+		t.Error("Expected error for invalid precision byte")
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Test: Delete Payload Decoder
 // -----------------------------------------------------------------------------
@@ -148,6 +394,33 @@ func TestDeletePayloadDecoder(t *testing.T) {
 	})
 }
 
+func TestUpdatePayloadDecoder(t *testing.T) {
+	original := &updatePayload{
+		externalID: "doc-upd",
+		internalID: 9,
+		kind:       UpdateSetKeys,
+		data:       []byte(`{"status":"done"}`),
+	}
+	encodedBytes := original.encode()
+	if uint32(len(encodedBytes)) != original.size() {
+		t.Fatalf("encoded %d bytes, size() reports %d", len(encodedBytes), original.size())
+	}
+	decoded, err := decodeUpdatePayload(encodedBytes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("Update payload mismatch.\nExpected: %+v\nGot:      %+v", original, decoded)
+	}
+	if _, err := decodeUpdatePayload(encodedBytes[:len(encodedBytes)-1]); err == nil {
+		t.Error("Expected error for truncated update payload")
+	}
+	encodedBytes[2+len(original.externalID)+8] = 9
+	if _, err := decodeUpdatePayload(encodedBytes); err == nil {
+		t.Error("Expected error for invalid update kind")
+	}
+}
+
 // -----------------------------------------------------------------------------
 // Test: Segment Header Decoder
 // -----------------------------------------------------------------------------
@@ -193,3 +466,33 @@ func TestDecodeSegmentHeader(t *testing.T) {
 		}
 	})
 }
+
+// -----------------------------------------------------------------------------
+// Test: Expiry times (walVersion 8) of dense and sparse inserts
+// -----------------------------------------------------------------------------
+func TestInsertPayloadDecoder_Expiry(t *testing.T) {
+	const expiresAt = int64(1_700_000_000_123_456_789)
+	dense := &insertPayload{externalID: "doc-ttl", internalID: 5, vectorData: []float32{1, 0}, expiresAt: expiresAt}
+	decoded, err := decodeInsertPayload(dense.encode(), walVersion)
+	if err != nil || decoded.expiresAt != expiresAt {
+		t.Fatalf("expected expiry %d, got %+v, %v", expiresAt, decoded, err)
+	}
+	if _, err := decodeInsertPayload(dense.encode()[:dense.size()-1], walVersion); err == nil {
+		t.Error("expected an error for a truncated expiry time")
+	}
+	// a version 7 reader stops before the expiry time
+	decoded, err = decodeInsertPayload(dense.encode(), 7)
+	if err != nil || decoded.expiresAt != 0 {
+		t.Errorf("version 7 decode should ignore the expiry time, got %+v, %v", decoded, err)
+	}
+
+	sparse := &sparseInsertPayload{externalID: "sparse-ttl", internalID: 6, indices: []uint32{1}, values: []float32{2}, expiresAt: expiresAt}
+	decodedSparse, err := decodeSparseInsertPayload(sparse.encode(), walVersion)
+	if err != nil || decodedSparse.expiresAt != expiresAt {
+		t.Fatalf("expected sparse expiry %d, got %+v, %v", expiresAt, decodedSparse, err)
+	}
+	decodedSparse, err = decodeSparseInsertPayload(sparse.encode(), 7)
+	if err != nil || decodedSparse.expiresAt != 0 {
+		t.Errorf("version 7 decode should ignore the sparse expiry time, got %+v, %v", decodedSparse, err)
+	}
+}
@@ -1,6 +1,10 @@
 package wal
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
 
 func FuzzDecodeRecordHeader(f *testing.F) {
 	f.Add([]byte("random-data"))
@@ -14,7 +18,8 @@ func FuzzDecodeInsertPayload(f *testing.F) {
 	f.Add([]byte("payload"))
 
 	f.Fuzz(func(t *testing.T, data []byte) {
-		_, _ = decodeInsertPayload(data)
+		_, _ = decodeInsertPayload(data, 1)
+		_, _ = decodeInsertPayload(data, walVersion)
 	})
 }
 
@@ -25,3 +30,21 @@ func FuzzDecodeDeletePayload(f *testing.F) {
 		_, _ = decodeDeletePayload(data)
 	})
 }
+
+func FuzzDecodeSparseInsertPayload(f *testing.F) {
+	f.Add([]byte("payload"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = decodeSparseInsertPayload(data, walVersion)
+	})
+}
+
+func FuzzDecodeMultiVectors(f *testing.F) {
+	f.Add([]byte("payload"))
+	// zero dimension with 2^31-1 rows
+	f.Add([]byte{1, 0, 1, 0, 'a', byte(types.Float32Precision), 0, 0, 0, 0, 0xff, 0xff, 0xff, 0x7f})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _, _ = decodeMultiVectors(data)
+	})
+}
@@ -3,6 +3,9 @@ package wal
 import (
 	"encoding/binary"
 	"math"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+	"github.com/Kasbe14/Dattaniddhi/internal/vector"
 )
 
 // type Optype uint8
@@ -16,23 +19,65 @@ type insertPayload struct {
 	externalID string
 	internalID uint64
 	vectorData []float32
+	precision  types.Precision
 	metaData   []byte
+	// sparse part of a hybrid point, empty for plain dense inserts
+	sparseIndices []uint32
+	sparseValues  []float32
+	// vectors of the point's named fields, empty for single vector collections
+	namedVectors []NamedVector
+	// token vectors of the point's multi vector fields
+	multiVectors []NamedMultiVector
+	// expiry time in unix nanoseconds, 0 never expires
+	expiresAt int64
+}
+
+// NamedVector is the vector a point holds for one named field of a multi-vector collection
+type NamedVector struct {
+	Name      string
+	Vector    []float32
+	Precision types.Precision
+}
+
+// NamedMultiVector is the bag of token vectors a point holds for one multi vector field,
+// every row has the same dimension
+type NamedMultiVector struct {
+	Name      string
+	Vectors   [][]float32
+	Precision types.Precision
 }
 
+// bytes per vector component on disk
+func componentSize(prec types.Precision) int {
+	if prec == types.Float32Precision {
+		return 4
+	}
+	return 2
+}
+
+// encodes the walVersion 8 layout
 func (ip *insertPayload) encode() []byte {
 	//2 -> maker; store len of external id [read this amount of next bytes for actual string data]
 	//  len(ip.ExternalID) -> total number of bytes of string
 	// 8 -> internalID
-	// 4->marker; VectorDimension [read (4 * len(ip.VectorData)) amout of bytes for vector data]
-	//(4 * len(ip.VectorData)) -> actual data
+	// 1 -> precision of the vector components [4 bytes float32, 2 bytes float16/bfloat16]
+	// 4->marker; VectorDimension [read (width * len(ip.VectorData)) amout of bytes for vector data]
+	//(width * len(ip.VectorData)) -> actual data
 	// 4-> marker; amount of bytes in meta data
 	// len(ip.Metadata)  bytes of metadata
+	// 4 -> marker; number of sparse pairs, 0 for plain dense points
+	// 8*nnz -> sparse pairs, same layout as the sparse insert payload
+	// 2 -> marker; number of named vectors, then per named vector:
+	//   2 -> name length, name bytes, 1 -> precision, 4 -> dimension, (width * dimension) -> data
+	// 2 -> marker; number of multi vectors, then per multi vector:
+	//   2 -> name length, name bytes, 1 -> precision, 4 -> dimension, 4 -> rows,
+	//   (width * dimension * rows) -> data, rows back to back
+	// 8 -> expiry time in unix nanoseconds, 0 never expires
 	extIdLen := len(ip.externalID)
 	vecDataLen := len(ip.vectorData)
 	metaDataLen := len(ip.metaData)
 
-	bufSize := 2 + extIdLen + 8 + 4 + (4 * vecDataLen) + 4 + metaDataLen
-	buf := make([]byte, bufSize)
+	buf := make([]byte, ip.size())
 	offset := 0
 	binary.LittleEndian.PutUint16(buf[offset:offset+2], uint16(extIdLen))
 	offset += 2
@@ -40,22 +85,73 @@ func (ip *insertPayload) encode() []byte {
 	offset += extIdLen
 	binary.LittleEndian.PutUint64(buf[offset:offset+8], ip.internalID)
 	offset += 8
+	buf[offset] = uint8(ip.precision)
+	offset += 1
 	binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(vecDataLen))
 	offset += 4
 	//vector data
-	for _, f := range ip.vectorData {
-		bits := math.Float32bits(f) // convert floaat values to raw bits uint32
-		binary.LittleEndian.PutUint32(buf[offset:offset+4], bits)
-		offset += 4
-	}
+	offset += encodeComponents(buf[offset:], ip.vectorData, ip.precision)
 
 	binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(metaDataLen))
 	offset += 4
 	copy(buf[offset:], ip.metaData)
+	offset += metaDataLen
+	offset += encodeSparsePairs(buf[offset:], ip.sparseIndices, ip.sparseValues)
+	binary.LittleEndian.PutUint16(buf[offset:offset+2], uint16(len(ip.namedVectors)))
+	offset += 2
+	for _, nv := range ip.namedVectors {
+		binary.LittleEndian.PutUint16(buf[offset:offset+2], uint16(len(nv.Name)))
+		offset += 2
+		copy(buf[offset:], nv.Name)
+		offset += len(nv.Name)
+		buf[offset] = uint8(nv.Precision)
+		offset += 1
+		binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(len(nv.Vector)))
+		offset += 4
+		offset += encodeComponents(buf[offset:], nv.Vector, nv.Precision)
+	}
+	binary.LittleEndian.PutUint16(buf[offset:offset+2], uint16(len(ip.multiVectors)))
+	offset += 2
+	for _, mv := range ip.multiVectors {
+		binary.LittleEndian.PutUint16(buf[offset:offset+2], uint16(len(mv.Name)))
+		offset += 2
+		copy(buf[offset:], mv.Name)
+		offset += len(mv.Name)
+		buf[offset] = uint8(mv.Precision)
+		offset += 1
+		binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(mv.dimension()))
+		offset += 4
+		binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(len(mv.Vectors)))
+		offset += 4
+		for _, row := range mv.Vectors {
+			offset += encodeComponents(buf[offset:], row, mv.Precision)
+		}
+	}
+	binary.LittleEndian.PutUint64(buf[offset:offset+8], uint64(ip.expiresAt))
 
 	return buf
 }
 
+// writes vector components at the given precision, returns the bytes written
+func encodeComponents(buf []byte, values []float32, prec types.Precision) int {
+	offset := 0
+	switch prec {
+	case types.Float32Precision:
+		for _, f := range values {
+			binary.LittleEndian.PutUint32(buf[offset:offset+4], math.Float32bits(f))
+			offset += 4
+		}
+	default:
+		halves := make([]uint16, len(values))
+		vector.EncodeHalf(values, prec, halves)
+		for _, h := range halves {
+			binary.LittleEndian.PutUint16(buf[offset:offset+2], h)
+			offset += 2
+		}
+	}
+	return offset
+}
+
 type deletePayload struct {
 	externalID string // 2-idlength
 	internalID uint64
@@ -76,11 +172,134 @@ func (dp *deletePayload) encode() []byte {
 }
 
 func (ip *insertPayload) size() uint32 {
-	return uint32(2 + len(ip.externalID) + 8 + 4 + (4 * len(ip.vectorData)) + 4 + len(ip.metaData))
+	return uint32(2 + len(ip.externalID) + 8 + 1 + 4 + (componentSize(ip.precision) * len(ip.vectorData)) + 4 + len(ip.metaData) + sparsePairsSize(len(ip.sparseIndices)) + namedVectorsSize(ip.namedVectors) + multiVectorsSize(ip.multiVectors) + 8)
+}
+
+func namedVectorsSize(named []NamedVector) int {
+	size := 2
+	for _, nv := range named {
+		size += 2 + len(nv.Name) + 1 + 4 + componentSize(nv.Precision)*len(nv.Vector)
+	}
+	return size
 }
+
+func multiVectorsSize(multi []NamedMultiVector) int {
+	size := 2
+	for _, mv := range multi {
+		size += 2 + len(mv.Name) + 1 + 4 + 4 + componentSize(mv.Precision)*mv.dimension()*len(mv.Vectors)
+	}
+	return size
+}
+
+// token dimension, zero for an empty bag
+func (mv NamedMultiVector) dimension() int {
+	if len(mv.Vectors) == 0 {
+		return 0
+	}
+	return len(mv.Vectors[0])
+}
+
 func (dp *deletePayload) size() uint32 {
 	return uint32(2 + len(dp.externalID) + 8)
 }
 
+type sparseInsertPayload struct {
+	externalID string
+	internalID uint64
+	indices    []uint32
+	values     []float32
+	metaData   []byte
+	// expiry time in unix nanoseconds, 0 never expires
+	expiresAt int64
+}
+
+func (sp *sparseInsertPayload) encode() []byte {
+	//2 -> maker; store len of external id
+	//  len(sp.ExternalID) -> total number of bytes of string
+	// 8 -> internalID
+	// 4 -> marker; number of non zero pairs
+	// 4*nnz -> vocabulary indices, then 4*nnz -> float32 values
+	// 4-> marker; amount of bytes in meta data
+	// len(sp.Metadata)  bytes of metadata
+	// 8 -> expiry time in unix nanoseconds, 0 never expires
+	extIDLen := len(sp.externalID)
+	buf := make([]byte, sp.size())
+	offset := 0
+	binary.LittleEndian.PutUint16(buf[offset:offset+2], uint16(extIDLen))
+	offset += 2
+	copy(buf[offset:], sp.externalID)
+	offset += extIDLen
+	binary.LittleEndian.PutUint64(buf[offset:offset+8], sp.internalID)
+	offset += 8
+	offset += encodeSparsePairs(buf[offset:], sp.indices, sp.values)
+	binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(len(sp.metaData)))
+	offset += 4
+	copy(buf[offset:], sp.metaData)
+	offset += len(sp.metaData)
+	binary.LittleEndian.PutUint64(buf[offset:offset+8], uint64(sp.expiresAt))
+	return buf
+}
+
+func (sp *sparseInsertPayload) size() uint32 {
+	return uint32(2 + len(sp.externalID) + 8 + sparsePairsSize(len(sp.indices)) + 4 + len(sp.metaData) + 8)
+}
+
+// bytes taken by a sparse pair section: nnz marker, indices, float32 values
+func sparsePairsSize(nnz int) int {
+	return 4 + 8*nnz
+}
+
+// writes nnz followed by all indices then all values, returns the bytes written
+func encodeSparsePairs(buf []byte, indices []uint32, values []float32) int {
+	offset := 0
+	binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(len(indices)))
+	offset += 4
+	for _, idx := range indices {
+		binary.LittleEndian.PutUint32(buf[offset:offset+4], idx)
+		offset += 4
+	}
+	for _, f := range values {
+		binary.LittleEndian.PutUint32(buf[offset:offset+4], math.Float32bits(f))
+		offset += 4
+	}
+	return offset
+}
+
 var _ payload = (*insertPayload)(nil)
 var _ payload = (*deletePayload)(nil)
+var _ payload = (*sparseInsertPayload)(nil)
+
+type updatePayload struct {
+	externalID string
+	internalID uint64
+	kind       uint8
+	data       []byte
+}
+
+func (up *updatePayload) encode() []byte {
+	//2 -> maker; store len of external id
+	//  len(up.ExternalID) -> total number of bytes of string
+	// 8 -> internalID
+	// 1 -> update kind
+	// 4 -> marker; amount of bytes in the delta
+	// len(up.data) bytes of the JSON delta
+	extIDLen := len(up.externalID)
+	buf := make([]byte, up.size())
+	offset := 0
+	binary.LittleEndian.PutUint16(buf[offset:offset+2], uint16(extIDLen))
+	offset += 2
+	copy(buf[offset:], up.externalID)
+	offset += extIDLen
+	binary.LittleEndian.PutUint64(buf[offset:offset+8], up.internalID)
+	offset += 8
+	buf[offset] = up.kind
+	offset += 1
+	binary.LittleEndian.PutUint32(buf[offset:offset+4], uint32(len(up.data)))
+	offset += 4
+	copy(buf[offset:], up.data)
+	return buf
+}
+
+func (up *updatePayload) size() uint32 {
+	return uint32(2 + len(up.externalID) + 8 + 1 + 4 + len(up.data))
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/binary"
 	"math"
 	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
 )
 
 func TestInsertPayload_SizeAndEncode(t *testing.T) {
@@ -15,7 +17,9 @@ func TestInsertPayload_SizeAndEncode(t *testing.T) {
 	}
 
 	// 1. Test Size Calculation
-	expectedSize := uint32(2 + len("doc-1") + 8 + 4 + (4 * 3) + 4 + len(`{"key":"value"}`))
+	// trailing 4+2+2+8 bytes: empty sparse section marker (walVersion 4), named vector count (walVersion 5),
+	// multi vector count (walVersion 6) and expiry time (walVersion 8)
+	expectedSize := uint32(2 + len("doc-1") + 8 + 1 + 4 + (4 * 3) + 4 + len(`{"key":"value"}`) + 4 + 2 + 2 + 8)
 	if ip.size() != expectedSize {
 		t.Errorf("Expected size %d, got %d", expectedSize, ip.size())
 	}
@@ -40,6 +44,11 @@ func TestInsertPayload_SizeAndEncode(t *testing.T) {
 	}
 	offset += 8
 
+	if encoded[offset] != uint8(types.Float32Precision) {
+		t.Errorf("Expected float32 precision byte, got %d", encoded[offset])
+	}
+	offset += 1
+
 	vecLen := binary.LittleEndian.Uint32(encoded[offset : offset+4])
 	if vecLen != 3 {
 		t.Errorf("Expected vector length 3, got %d", vecLen)
@@ -75,3 +84,14 @@ func TestDeletePayload_SizeAndEncode(t *testing.T) {
 		t.Errorf("Expected external ID length 5, got %d", extLen)
 	}
 }
+
+func TestInsertPayload_HalfPrecisionHalvesVectorBytes(t *testing.T) {
+	full := &insertPayload{externalID: "d", internalID: 1, vectorData: make([]float32, 1536)}
+	half := &insertPayload{externalID: "d", internalID: 1, vectorData: make([]float32, 1536), precision: types.Float16Precision}
+	if diff := full.size() - half.size(); diff != 2*1536 {
+		t.Errorf("Expected float16 payload to be %d bytes smaller, got %d", 2*1536, diff)
+	}
+	if len(half.encode()) != int(half.size()) {
+		t.Errorf("Encoded length %d does not match size %d", len(half.encode()), half.size())
+	}
+}
@@ -5,16 +5,30 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
 )
 
 // data required for recovery
 type DecodedRecords struct {
-	LSN      uint64
-	OpType   uint8
-	ExtID    string
-	IntID    uint64
-	Vector   []float32 // Only populated for Inserts
-	MetaData []byte    // Only populated for Inserts
+	LSN       uint64
+	OpType    uint8
+	ExtID     string
+	IntID     uint64
+	Vector    []float32       // Only populated for Inserts, half precision values already widened
+	Precision types.Precision // Only populated for Inserts
+	MetaData  []byte          // Only populated for Inserts, the JSON delta for Updates
+	// Only populated for Updates, one of the Update* kinds
+	UpdateKind uint8
+	// Only populated for sparse inserts (Vector is nil for those) and hybrid inserts
+	SparseIndices []uint32
+	SparseValues  []float32
+	// Only populated for inserts into collections with named vector fields
+	NamedVectors []NamedVector
+	// Only populated for inserts into collections with multi vector fields
+	MultiVectors []NamedMultiVector
+	// Only populated for inserts (dense and sparse), expiry time in unix nanoseconds, 0 never expires
+	ExpiresAt int64
 }
 
 // scans all the segment files validates and returns the records written to the segment file
@@ -95,14 +109,32 @@ func scanSegmentFile(segment *segment) ([]DecodedRecords, error) {
 		//var decodedPayloadBytes any
 		switch decodedRecordHeader.opType {
 		case OpInsert:
-			decodedPayloadBytes, err := decodeInsertPayload(payloadBytes)
+			decodedPayloadBytes, err := decodeInsertPayload(payloadBytes, decodedRecordHeader.version)
 			if err != nil {
 				return nil, fmt.Errorf("failed to decode insert payload in segment %d: %w", segment.segID, err)
 			}
 			singleRecord.ExtID = decodedPayloadBytes.externalID
 			singleRecord.IntID = decodedPayloadBytes.internalID
 			singleRecord.Vector = decodedPayloadBytes.vectorData
+			singleRecord.Precision = decodedPayloadBytes.precision
+			singleRecord.MetaData = decodedPayloadBytes.metaData
+			singleRecord.SparseIndices = decodedPayloadBytes.sparseIndices
+			singleRecord.SparseValues = decodedPayloadBytes.sparseValues
+			singleRecord.NamedVectors = decodedPayloadBytes.namedVectors
+			singleRecord.MultiVectors = decodedPayloadBytes.multiVectors
+			singleRecord.ExpiresAt = decodedPayloadBytes.expiresAt
+
+		case OpInsertSparse:
+			decodedPayloadBytes, err := decodeSparseInsertPayload(payloadBytes, decodedRecordHeader.version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode sparse insert payload in segment %d: %w", segment.segID, err)
+			}
+			singleRecord.ExtID = decodedPayloadBytes.externalID
+			singleRecord.IntID = decodedPayloadBytes.internalID
+			singleRecord.SparseIndices = decodedPayloadBytes.indices
+			singleRecord.SparseValues = decodedPayloadBytes.values
 			singleRecord.MetaData = decodedPayloadBytes.metaData
+			singleRecord.ExpiresAt = decodedPayloadBytes.expiresAt
 
 		case OpDelete:
 			decodedPayloadBytes, err := decodeDeletePayload(payloadBytes)
@@ -111,9 +143,16 @@ func scanSegmentFile(segment *segment) ([]DecodedRecords, error) {
 			}
 			singleRecord.ExtID = decodedPayloadBytes.externalID
 			singleRecord.IntID = decodedPayloadBytes.internalID
-			//TODO : update operation ,format, payload , deocode
-			/*case OpDelete:
-			decodedPayloadBytes, err = decodeUpdatePayload(payloadBytes)*/
+
+		case OpUpdate:
+			decodedPayloadBytes, err := decodeUpdatePayload(payloadBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode update payload in segment %d: %w", segment.segID, err)
+			}
+			singleRecord.ExtID = decodedPayloadBytes.externalID
+			singleRecord.IntID = decodedPayloadBytes.internalID
+			singleRecord.UpdateKind = decodedPayloadBytes.kind
+			singleRecord.MetaData = decodedPayloadBytes.data
 		default:
 			return nil, fmt.Errorf("invalid Operation type")
 		}
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
 )
 
 type SyncPolicy int
@@ -28,9 +30,22 @@ const (
 	// Operation Types
 	OpInsert uint8 = 1
 	OpDelete uint8 = 2
+	// payload delta of an existing point, the vectors are not logged again
 	OpUpdate uint8 = 3
+	// insert of a sparse vector, index/value pairs instead of a dense component array
+	OpInsertSparse uint8 = 4
 	//Version
-	walVersion uint8 = 1
+	// 1: insert payload always carries float32 components
+	// 2: insert payload carries a precision byte and 2 or 4 bytes per component
+	// 3: adds sparse insert records
+	// 4: insert payload ends with an optional sparse section (hybrid points)
+	// 5: insert payload ends with the vectors of named fields
+	// 6: insert payload ends with the token vectors of named multi vector fields
+	// 7: adds payload update records
+	// 8: insert and sparse insert payloads end with the point's expiry time
+	walVersion uint8 = 8
+	// oldest version this build can still replay
+	minWALVersion uint8 = 1
 	//max segment file size 64mb
 	maxSegmentFileSize uint64 = 64 * 1024 * 1024
 	//minimum reocrd lenght 36 = record header 32byetes + checksum 4 bytes
@@ -194,58 +209,131 @@ func getLatestLSN(activeSeg segment) (uint64, error) {
 }
 
 func (wal *WAL) AppendInsert(extID string, intID uint64, vecData []float32, metaData []byte) (uint64, error) {
-	// before lock (concurrent) creating and encooding the payload
-	//creating payload
+	return wal.AppendInsertWithPrecision(extID, intID, vecData, types.Float32Precision, metaData)
+}
+
+// AppendInsertWithPrecision logs vecData narrowed to prec, half precision records take 2 bytes per component
+func (wal *WAL) AppendInsertWithPrecision(extID string, intID uint64, vecData []float32, prec types.Precision, metaData []byte) (uint64, error) {
+	return wal.AppendInsertHybrid(extID, intID, vecData, prec, nil, nil, metaData)
+}
+
+// InsertRecord is every representation of one point logged by AppendInsertPoint
+type InsertRecord struct {
+	ExtID     string
+	IntID     uint64
+	Vector    []float32
+	Precision types.Precision
+	// sparse part of hybrid points, may be empty
+	SparseIndices []uint32
+	SparseValues  []float32
+	// vectors of named fields, may be empty
+	NamedVectors []NamedVector
+	// token vectors of named multi vector fields, may be empty
+	MultiVectors []NamedMultiVector
+	MetaData     []byte
+	// expiry time in unix nanoseconds, 0 never expires
+	ExpiresAt int64
+}
+
+// AppendInsertPoint logs all vectors of one point in a single record, so replay never sees part of a point
+func (wal *WAL) AppendInsertPoint(rec InsertRecord) (uint64, error) {
+	if !validPrecision(rec.Precision) {
+		return 0, fmt.Errorf("invalid vector precision %d", rec.Precision)
+	}
+	if len(rec.SparseIndices) != len(rec.SparseValues) {
+		return 0, fmt.Errorf("sparse vector indices and values length mismatch")
+	}
+	for _, nv := range rec.NamedVectors {
+		if !validPrecision(nv.Precision) {
+			return 0, fmt.Errorf("invalid precision %d for named vector %s", nv.Precision, nv.Name)
+		}
+	}
+	for _, mv := range rec.MultiVectors {
+		if !validPrecision(mv.Precision) {
+			return 0, fmt.Errorf("invalid precision %d for multi vector %s", mv.Precision, mv.Name)
+		}
+		if mv.dimension() == 0 {
+			return 0, fmt.Errorf("multi vector %s has no token vectors", mv.Name)
+		}
+		for _, row := range mv.Vectors {
+			if len(row) != len(mv.Vectors[0]) {
+				return 0, fmt.Errorf("multi vector %s rows differ in dimension", mv.Name)
+			}
+		}
+	}
 	pl := &insertPayload{
+		externalID: rec.ExtID,
+		internalID: rec.IntID,
+		vectorData: rec.Vector,
+		precision:  rec.Precision,
+		metaData:   rec.MetaData,
+
+		sparseIndices: rec.SparseIndices,
+		sparseValues:  rec.SparseValues,
+		namedVectors:  rec.NamedVectors,
+		multiVectors:  rec.MultiVectors,
+		expiresAt:     rec.ExpiresAt,
+	}
+	return wal.appendRecord(OpInsert, pl.encode())
+}
+
+// AppendInsertHybrid logs a dense vector together with the sparse part of the same point in one record,
+// empty sparse slices log a plain dense insert
+func (wal *WAL) AppendInsertHybrid(extID string, intID uint64, vecData []float32, prec types.Precision, sparseIndices []uint32, sparseValues []float32, metaData []byte) (uint64, error) {
+	return wal.AppendInsertPoint(InsertRecord{
+		ExtID:         extID,
+		IntID:         intID,
+		Vector:        vecData,
+		Precision:     prec,
+		SparseIndices: sparseIndices,
+		SparseValues:  sparseValues,
+		MetaData:      metaData,
+	})
+}
+
+// AppendInsertSparse logs a sparse vector as its sorted index/value pairs
+func (wal *WAL) AppendInsertSparse(extID string, intID uint64, indices []uint32, values []float32, metaData []byte) (uint64, error) {
+	return wal.AppendInsertSparseExpiring(extID, intID, indices, values, 0, metaData)
+}
+
+// AppendInsertSparseExpiring logs a sparse vector that expires at expiresAt (unix nanoseconds, 0 never)
+func (wal *WAL) AppendInsertSparseExpiring(extID string, intID uint64, indices []uint32, values []float32, expiresAt int64, metaData []byte) (uint64, error) {
+	if len(indices) != len(values) {
+		return 0, fmt.Errorf("sparse vector indices and values length mismatch")
+	}
+	pl := &sparseInsertPayload{
 		externalID: extID,
 		internalID: intID,
-		vectorData: vecData,
+		indices:    indices,
+		values:     values,
 		metaData:   metaData,
+		expiresAt:  expiresAt,
 	}
-	//encode payload
-	payloadBytes := pl.encode()
-	// payloadSize := pl.size()
-	//locking for go routines writes
-	wal.mu.Lock()
-	defer wal.mu.Unlock()
-	//update the lsn
-	wal.lsn++
-	currentLSN := wal.lsn
-	//create and enocde the recordWrapper to write to segment
-	rh := newRecordHeader(walVersion, currentLSN, OpInsert)
-	rw := newRecordWrapper(*rh, payloadBytes)
-	recordWrapperBytes := rw.encode()
+	return wal.appendRecord(OpInsertSparse, pl.encode())
+}
 
-	//roll over policy if segment file >= 64mb
-	segmentFileSize := uint64(len(recordWrapperBytes)) + wal.activeSegment.currentSize
-	if segmentFileSize > maxSegmentFileSize {
-		//New segment file with new SegId and SegmentHeader written
-		err := wal.rotateSegment()
-		if err != nil {
-			return 0, fmt.Errorf("failed to rotate segment: %w", err)
-		}
-	}
-	//append to the segment file (new segment file if rotated or same)
-	bytesAppended, err := wal.activeSegment.append(recordWrapperBytes)
-	if bytesAppended != len(recordWrapperBytes) {
-		return 0, fmt.Errorf("failed to write record wrapper bytes: %w", err)
-	}
-	if err != nil {
-		return 0, fmt.Errorf("failed to write record wrapper bytes: %w", err)
+// kinds of payload update an OpUpdate record carries
+const (
+	// data is a JSON object whose keys are set on the payload
+	UpdateSetKeys uint8 = 1
+	// data is a JSON array of keys removed from the payload
+	UpdateDeleteKeys uint8 = 2
+	// data is the whole new payload
+	UpdateOverwrite uint8 = 3
+)
+
+// AppendUpdatePayload logs a payload change of an existing point as its kind and JSON delta
+func (wal *WAL) AppendUpdatePayload(extID string, intID uint64, kind uint8, data []byte) (uint64, error) {
+	if !validUpdateKind(kind) {
+		return 0, fmt.Errorf("invalid payload update kind %d", kind)
 	}
-	// write bytes to disk according to sync policy
-	switch wal.syncPolicy {
-	case SyncAlways:
-		err := wal.activeSegment.file.Sync()
-		if err != nil {
-			return 0, fmt.Errorf("failed to sync segment %d: %w", wal.activeSegment.segID, err)
-		}
-	case SyncOS:
-		// do nothing os handles the sync
-	case SyncEverySec:
-		//do nothing TODO: separate background functon to handle this sync
+	pl := &updatePayload{
+		externalID: extID,
+		internalID: intID,
+		kind:       kind,
+		data:       data,
 	}
-	return currentLSN, nil
+	return wal.appendRecord(OpUpdate, pl.encode())
 }
 
 func (wal *WAL) AppendDelete(extID string, intID uint64) (uint64, error) {
@@ -254,26 +342,39 @@ func (wal *WAL) AppendDelete(extID string, intID uint64) (uint64, error) {
 		externalID: extID,
 		internalID: intID,
 	}
-	payloadBytes := pl.encode()
-	//lock to write sequntially
+	return wal.appendRecord(OpDelete, pl.encode())
+}
+
+// LSN returns the sequence number of the last appended record, 0 for an empty log
+func (wal *WAL) LSN() uint64 {
 	wal.mu.Lock()
 	defer wal.mu.Unlock()
-	//update lsn and append payload
+	return wal.lsn
+}
+
+// frames an encoded payload into a record, assigns the next lsn and appends it to the active segment
+func (wal *WAL) appendRecord(op uint8, payloadBytes []byte) (uint64, error) {
+	//locking for go routines writes
+	wal.mu.Lock()
+	defer wal.mu.Unlock()
+	//update the lsn
 	wal.lsn++
 	currentLSN := wal.lsn
-	rh := newRecordHeader(walVersion, wal.lsn, OpDelete)
+	//create and enocde the recordWrapper to write to segment
+	rh := newRecordHeader(walVersion, currentLSN, op)
 	rw := newRecordWrapper(*rh, payloadBytes)
 	recordWrapperBytes := rw.encode()
 
 	//roll over policy if segment file >= 64mb
 	segmentFileSize := uint64(len(recordWrapperBytes)) + wal.activeSegment.currentSize
 	if segmentFileSize > maxSegmentFileSize {
+		//New segment file with new SegId and SegmentHeader written
 		err := wal.rotateSegment()
 		if err != nil {
 			return 0, fmt.Errorf("failed to rotate segment: %w", err)
 		}
 	}
-	// write record wrapper bytes to the segment file
+	//append to the segment file (new segment file if rotated or same)
 	bytesAppended, err := wal.activeSegment.append(recordWrapperBytes)
 	if bytesAppended != len(recordWrapperBytes) {
 		return 0, fmt.Errorf("failed to write record wrapper bytes: %w", err)
@@ -281,7 +382,7 @@ func (wal *WAL) AppendDelete(extID string, intID uint64) (uint64, error) {
 	if err != nil {
 		return 0, fmt.Errorf("failed to write record wrapper bytes: %w", err)
 	}
-	// write bytes to disk
+	// write bytes to disk according to sync policy
 	switch wal.syncPolicy {
 	case SyncAlways:
 		err := wal.activeSegment.file.Sync()
@@ -289,10 +390,9 @@ func (wal *WAL) AppendDelete(extID string, intID uint64) (uint64, error) {
 			return 0, fmt.Errorf("failed to sync segment %d: %w", wal.activeSegment.segID, err)
 		}
 	case SyncOS:
-		//do nothing OS handles
+		// do nothing os handles the sync
 	case SyncEverySec:
-		//Todo backgorund go rouine to handle sync every sec
-
+		//do nothing TODO: separate background functon to handle this sync
 	}
 	return currentLSN, nil
 }
@@ -357,8 +457,6 @@ func (wal *WAL) Close() error {
 	return closeErr
 }
 
-// TODO : update operation uisng delete and insert 2 operations for now later crate and update payload
-
 // background go routine for SyncEverySec fsync policy
 func (wal *WAL) backgroundSync() {
 	//signaling waitgroup this fucntion has exited
@@ -29,6 +29,9 @@ func TestWAL_LifecycleAndRecovery(t *testing.T) {
 	if lsn1 != 1 || lsn2 != 2 {
 		t.Errorf("Expected LSNs 1 and 2, got %d and %d", lsn1, lsn2)
 	}
+	if wal.LSN() != lsn2 {
+		t.Errorf("Expected LSN() to report the last record %d, got %d", lsn2, wal.LSN())
+	}
 
 	// Close the file to simulate server shutdown
 	wal.activeSegment.file.Close()
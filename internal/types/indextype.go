@@ -7,6 +7,8 @@ const (
 	HNSWIndex
 	IVFIndex
 	PQIndex
+	// posting lists over sparse vectors, scored by sparse dot product
+	InvertedIndex
 )
 
 // Implement stinger interface to convert to string
@@ -20,6 +22,8 @@ func (it IndexType) String() string {
 		return "IVFIndex"
 	case PQIndex:
 		return "PQIndex"
+	case InvertedIndex:
+		return "InvertedIndex"
 	default:
 		panic("unsupported Index Type")
 	}
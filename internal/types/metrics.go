@@ -6,6 +6,14 @@ const (
 	Cosine SimilarityMetric = iota + 1
 	Dot
 	Euclidean
+	// dot product on the raw values, vectors are not normalized
+	InnerProduct
+	// L1 distance on the raw values
+	Manhattan
+	// number of differing components, for binary (0/1) vectors
+	Hamming
+	// intersection over union, for set-like non negative vectors
+	Jaccard
 )
 
 //Todo implement stringer interface on similaritymetric
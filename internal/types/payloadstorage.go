@@ -0,0 +1,12 @@
+package types
+
+// PayloadStorage is where a collection keeps point payloads
+// zero value is the in-memory map so older configs stay valid
+type PayloadStorage int
+
+const (
+	// payloads live in RAM, rebuilt from the WAL on open
+	InMemoryPayloads PayloadStorage = iota
+	// payloads live in a log structured file next to the WAL and are read on Get
+	OnDiskPayloads
+)
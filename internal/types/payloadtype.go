@@ -0,0 +1,34 @@
+package types
+
+import "strconv"
+
+// PayloadType is the type of one field of a payload schema
+type PayloadType int
+
+const (
+	StringPayload PayloadType = iota + 1
+	// whole numbers, decoded as int64 so large ids survive the JSON round trip
+	IntPayload
+	// decoded as float64
+	FloatPayload
+	BoolPayload
+	// list of strings, e.g. tags, decoded as []string
+	StringListPayload
+)
+
+func (pt PayloadType) String() string {
+	switch pt {
+	case StringPayload:
+		return "string"
+	case IntPayload:
+		return "int"
+	case FloatPayload:
+		return "float"
+	case BoolPayload:
+		return "bool"
+	case StringListPayload:
+		return "string list"
+	default:
+		return "PayloadType(" + strconv.Itoa(int(pt)) + ")"
+	}
+}
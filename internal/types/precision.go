@@ -0,0 +1,13 @@
+package types
+
+// Precision is the width vectors are stored with, on disk (WAL) and in the index
+// zero value is full float32 so older configs stay valid
+type Precision int
+
+const (
+	Float32Precision Precision = iota
+	// IEEE 754 half precision, 2 bytes per component, ~3 decimal digits, max 65504
+	Float16Precision
+	// truncated float32 (8 bit exponent, 7 bit mantissa), 2 bytes per component, float32 range
+	BFloat16Precision
+)
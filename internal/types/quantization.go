@@ -0,0 +1,13 @@
+package types
+
+// Quantization selects how an index stores vectors in memory
+// zero value keeps the original full precision float32 storage so older configs stay valid
+type Quantization int
+
+const (
+	NoQuantization Quantization = iota
+	// int8 codes with a per-vector scale and offset, 4x smaller than float32
+	ScalarQuantization
+	// one sign bit per dimension searched by hamming distance, 32x smaller than float32
+	BinaryQuantization
+)
@@ -0,0 +1,33 @@
+package vector
+
+import "math/bits"
+
+// Binary quantization helpers
+// a vector is reduced to the signs of its components, bit i of the code is set when values[i] > 0.
+// For normalized vectors the hamming distance between codes tracks the angle between the
+// vectors, which makes it a cheap pre-filter before exact cosine rescoring.
+
+// BinaryWords is the number of uint64 words holding the sign bits of a dim-dimensional vector
+func BinaryWords(dim int) int {
+	return (dim + 63) / 64
+}
+
+// PackSigns writes the sign bits of values into dst, dst must hold BinaryWords(len(values)) words
+func PackSigns(values []float32, dst []uint64) {
+	clear(dst[:BinaryWords(len(values))])
+	for i, x := range values {
+		if x > 0 {
+			dst[i/64] |= 1 << (i % 64)
+		}
+	}
+}
+
+// HammingDistance counts differing bits, len(b) must be at least len(a)
+func HammingDistance(a, b []uint64) int {
+	b = b[:len(a)]
+	dist := 0
+	for i := range a {
+		dist += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return dist
+}
@@ -0,0 +1,190 @@
+package vector
+
+import (
+	"math"
+	"sync"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+// Half precision (float16 / bfloat16) support
+// values are carried around as raw uint16 bits and widened to float32 on the fly while scoring,
+// storage and WAL size halve while the math stays in float32.
+
+// Float32ToFloat16 rounds f to the nearest IEEE 754 half (ties to even), overflow becomes ±Inf
+func Float32ToFloat16(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int((b >> 23) & 0xff)
+	mant := b & 0x7fffff
+	if exp == 0xff {
+		if mant != 0 {
+			return sign | 0x7e00 // NaN
+		}
+		return sign | 0x7c00 // Inf
+	}
+	// rebias exponent from float32 (127) to float16 (15)
+	e := exp - 127 + 15
+	if e >= 0x1f {
+		return sign | 0x7c00
+	}
+	if e <= 0 {
+		// subnormal half or zero
+		if e < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint32(14 - e)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	}
+	half := uint32(e)<<10 | mant>>13
+	rem := mant & 0x1fff
+	// a carry out of the mantissa correctly bumps the exponent, up to Inf
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++
+	}
+	return sign | uint16(half)
+}
+
+// Float16ToFloat32 widens a half exactly
+func Float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+	switch exp {
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// subnormal half is a normal float32, shift the leading one into place
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		mant &= 0x3ff
+		return math.Float32frombits(sign | e<<23 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
+
+// Float32ToBFloat16 rounds f to the nearest bfloat16 (ties to even)
+func Float32ToBFloat16(f float32) uint16 {
+	b := math.Float32bits(f)
+	if b&0x7fffffff > 0x7f800000 {
+		// keep NaN a NaN after dropping the low mantissa bits
+		return uint16(b>>16) | 0x40
+	}
+	b += 0x7fff + (b>>16)&1
+	return uint16(b >> 16)
+}
+
+// BFloat16ToFloat32 widens a bfloat16 exactly
+func BFloat16ToFloat32(h uint16) float32 {
+	return math.Float32frombits(uint32(h) << 16)
+}
+
+// every float16 widened once, 256KiB, built on first use so float32-only processes never pay for it
+var float16Table = sync.OnceValue(func() *[1 << 16]float32 {
+	var table [1 << 16]float32
+	for i := range table {
+		table[i] = Float16ToFloat32(uint16(i))
+	}
+	return &table
+})
+
+// EncodeHalf converts values into dst (len(dst) >= len(values)) with the given half precision
+func EncodeHalf(values []float32, prec types.Precision, dst []uint16) {
+	for i, x := range values {
+		if prec == types.BFloat16Precision {
+			dst[i] = Float32ToBFloat16(x)
+		} else {
+			dst[i] = Float32ToFloat16(x)
+		}
+	}
+}
+
+// DecodeHalf widens half precision bits into dst (len(dst) >= len(src))
+func DecodeHalf(src []uint16, prec types.Precision, dst []float32) {
+	if prec == types.BFloat16Precision {
+		for i, h := range src {
+			dst[i] = BFloat16ToFloat32(h)
+		}
+		return
+	}
+	table := float16Table()
+	for i, h := range src {
+		dst[i] = table[h]
+	}
+}
+
+// RoundToPrecision rounds values in place to what the precision can represent
+func RoundToPrecision(values []float32, prec types.Precision) {
+	switch prec {
+	case types.Float16Precision:
+		for i, x := range values {
+			values[i] = Float16ToFloat32(Float32ToFloat16(x))
+		}
+	case types.BFloat16Precision:
+		for i, x := range values {
+			values[i] = BFloat16ToFloat32(Float32ToBFloat16(x))
+		}
+	}
+}
+
+// DotHalf returns query·h widening h on the fly, len(h) must be at least len(query)
+func DotHalf(query []float32, h []uint16, prec types.Precision) float32 {
+	h = h[:len(query)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	if prec == types.BFloat16Precision {
+		for ; i+4 <= len(query); i += 4 {
+			s0 += query[i] * BFloat16ToFloat32(h[i])
+			s1 += query[i+1] * BFloat16ToFloat32(h[i+1])
+			s2 += query[i+2] * BFloat16ToFloat32(h[i+2])
+			s3 += query[i+3] * BFloat16ToFloat32(h[i+3])
+		}
+		for ; i < len(query); i++ {
+			s0 += query[i] * BFloat16ToFloat32(h[i])
+		}
+		return (s0 + s1) + (s2 + s3)
+	}
+	table := float16Table()
+	for ; i+4 <= len(query); i += 4 {
+		s0 += query[i] * table[h[i]]
+		s1 += query[i+1] * table[h[i+1]]
+		s2 += query[i+2] * table[h[i+2]]
+		s3 += query[i+3] * table[h[i+3]]
+	}
+	for ; i < len(query); i++ {
+		s0 += query[i] * table[h[i]]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// SquaredEuclideanHalf returns the squared L2 distance between query and h widening h on the fly
+func SquaredEuclideanHalf(query []float32, h []uint16, prec types.Precision) float32 {
+	h = h[:len(query)]
+	var sum float32
+	if prec == types.BFloat16Precision {
+		for i, q := range query {
+			d := q - BFloat16ToFloat32(h[i])
+			sum += d * d
+		}
+		return sum
+	}
+	table := float16Table()
+	for i, q := range query {
+		d := q - table[h[i]]
+		sum += d * d
+	}
+	return sum
+}
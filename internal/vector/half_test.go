@@ -0,0 +1,109 @@
+package vector
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+// Property: every non-NaN float16 survives widening and narrowing unchanged.
+func TestFloat16_RoundTripExhaustive(t *testing.T) {
+	for i := 0; i < 1<<16; i++ {
+		h := uint16(i)
+		f := Float16ToFloat32(h)
+		if math.IsNaN(float64(f)) {
+			continue
+		}
+		if back := Float32ToFloat16(f); back != h {
+			t.Fatalf("float16 %#04x widened to %v narrowed to %#04x", h, f, back)
+		}
+	}
+}
+
+// Property: every non-NaN bfloat16 survives widening and narrowing unchanged.
+func TestBFloat16_RoundTripExhaustive(t *testing.T) {
+	for i := 0; i < 1<<16; i++ {
+		h := uint16(i)
+		f := BFloat16ToFloat32(h)
+		if math.IsNaN(float64(f)) {
+			continue
+		}
+		if back := Float32ToBFloat16(f); back != h {
+			t.Fatalf("bfloat16 %#04x widened to %v narrowed to %#04x", h, f, back)
+		}
+	}
+}
+
+func TestFloat32ToFloat16_KnownValues(t *testing.T) {
+	tests := []struct {
+		in   float32
+		want uint16
+	}{
+		{0, 0x0000},
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{65504, 0x7bff},                  // max half
+		{65520, 0x7c00},                  // rounds up to Inf
+		{float32(math.Inf(-1)), 0xfc00},  // -Inf
+		{5.960464477539063e-08, 0x0001},  // smallest subnormal
+		{1 + 1.0/2048, 0x3c00},           // tie rounds to even (down)
+		{1 + 3.0/2048, 0x3c02},           // tie rounds to even (up)
+		{2.9802322387695312e-08, 0x0000}, // half of the smallest subnormal ties to zero
+	}
+	for _, tt := range tests {
+		if got := Float32ToFloat16(tt.in); got != tt.want {
+			t.Errorf("Float32ToFloat16(%v) = %#04x, want %#04x", tt.in, got, tt.want)
+		}
+	}
+	if h := Float32ToFloat16(float32(math.NaN())); Float16ToFloat32(h) == Float16ToFloat32(h) {
+		t.Error("NaN did not stay NaN")
+	}
+}
+
+// Property: the widening kernels match float32 kernels run on the widened values.
+func TestHalfKernels_MatchWidened(t *testing.T) {
+	r := rand.New(rand.NewSource(34))
+	for _, prec := range []types.Precision{types.Float16Precision, types.BFloat16Precision} {
+		for _, n := range kernelTestLengths {
+			query, vals := randomValues(r, n), randomValues(r, n)
+			h := make([]uint16, n)
+			EncodeHalf(vals, prec, h)
+			wide := make([]float32, n)
+			DecodeHalf(h, prec, wide)
+			tol := kernelTolerance(query, wide)
+			if d := math.Abs(float64(DotHalf(query, h, prec) - DotFloat32(query, wide))); d > tol {
+				t.Errorf("precision %v len %d: DotHalf off by %v", prec, n, d)
+			}
+			if d := math.Abs(float64(SquaredEuclideanHalf(query, h, prec) - SquaredEuclideanFloat32(query, wide))); d > tol {
+				t.Errorf("precision %v len %d: SquaredEuclideanHalf off by %v", prec, n, d)
+			}
+		}
+	}
+}
+
+// Post-condition: NewVectorWithPrecision values are exactly representable in the precision.
+func TestNewVectorWithPrecision(t *testing.T) {
+	for _, prec := range []types.Precision{types.Float32Precision, types.Float16Precision, types.BFloat16Precision} {
+		vec, err := NewVectorWithPrecision([]float32{3, 4, 0.1}, 3, prec)
+		if err != nil {
+			t.Fatalf("precision %v: %v", prec, err)
+		}
+		vals := vec.Values()
+		rounded := make([]float32, len(vals))
+		copy(rounded, vals)
+		RoundToPrecision(rounded, prec)
+		for i := range vals {
+			if vals[i] != rounded[i] {
+				t.Errorf("precision %v: component %d (%v) not representable", prec, i, vals[i])
+			}
+		}
+		if math.Abs(Magnitude(vals)-1) > 0.01 {
+			t.Errorf("precision %v: magnitude drifted to %v", prec, Magnitude(vals))
+		}
+	}
+	if _, err := NewVectorWithPrecision([]float32{1}, 1, types.Precision(9)); err == nil {
+		t.Error("expected error for unknown precision")
+	}
+}
@@ -0,0 +1,75 @@
+package vector
+
+// float32 distance kernels for the index hot path
+// Unlike DotProduct/Cosine/Euclidean these do not validate lengths or widen to float64,
+// callers (the index) check dimensions once per query and the kernels only do math.
+// Accumulation is split over independent lanes so the compiler can keep several
+// multiply-adds in flight, the same layout the amd64 assembly uses with vector registers.
+
+// DotFloat32 returns a·b, len(b) must be at least len(a)
+func DotFloat32(a, b []float32) float32 {
+	return dotKernel(a, b[:len(a)])
+}
+
+// SquaredEuclideanFloat32 returns the squared L2 distance between a and b, len(b) must be at least len(a)
+func SquaredEuclideanFloat32(a, b []float32) float32 {
+	return squaredEuclideanKernel(a, b[:len(a)])
+}
+
+// DotBatch scores one query against len(out) vectors stored back to back in data,
+// out[i] = query·data[i*len(query):(i+1)*len(query)]
+func DotBatch(query, data []float32, out []float32) {
+	dim := len(query)
+	_ = data[:len(out)*dim]
+	for i := range out {
+		out[i] = dotKernel(query, data[i*dim:(i+1)*dim])
+	}
+}
+
+// SquaredEuclideanBatch is DotBatch for the squared L2 distance
+func SquaredEuclideanBatch(query, data []float32, out []float32) {
+	dim := len(query)
+	_ = data[:len(out)*dim]
+	for i := range out {
+		out[i] = squaredEuclideanKernel(query, data[i*dim:(i+1)*dim])
+	}
+}
+
+// portable kernels, always compiled so they can be tested against the assembly ones
+
+func dotGeneric(a, b []float32) float32 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+8 <= len(a); i += 8 {
+		s0 += a[i]*b[i] + a[i+4]*b[i+4]
+		s1 += a[i+1]*b[i+1] + a[i+5]*b[i+5]
+		s2 += a[i+2]*b[i+2] + a[i+6]*b[i+6]
+		s3 += a[i+3]*b[i+3] + a[i+7]*b[i+7]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+func squaredEuclideanGeneric(a, b []float32) float32 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0 := a[i] - b[i]
+		d1 := a[i+1] - b[i+1]
+		d2 := a[i+2] - b[i+2]
+		d3 := a[i+3] - b[i+3]
+		s0 += d0 * d0
+		s1 += d1 * d1
+		s2 += d2 * d2
+		s3 += d3 * d3
+	}
+	for ; i < len(a); i++ {
+		d := a[i] - b[i]
+		s0 += d * d
+	}
+	return (s0 + s1) + (s2 + s3)
+}
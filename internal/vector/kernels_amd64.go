@@ -0,0 +1,28 @@
+//go:build amd64 && !purego
+
+package vector
+
+// set once at startup, AVX2 kernels also need FMA and OS support for the ymm state
+var useAVX2 = hasAVX2FMA()
+
+// implemented in kernels_amd64.s
+func hasAVX2FMA() bool
+func dotAVX2(a, b []float32) float32
+func squaredEuclideanAVX2(a, b []float32) float32
+
+// inputs below one ymm register are not worth the call
+const avx2MinLen = 8
+
+func dotKernel(a, b []float32) float32 {
+	if useAVX2 && len(a) >= avx2MinLen {
+		return dotAVX2(a, b)
+	}
+	return dotGeneric(a, b)
+}
+
+func squaredEuclideanKernel(a, b []float32) float32 {
+	if useAVX2 && len(a) >= avx2MinLen {
+		return squaredEuclideanAVX2(a, b)
+	}
+	return squaredEuclideanGeneric(a, b)
+}
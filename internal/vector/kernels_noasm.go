@@ -0,0 +1,13 @@
+//go:build !amd64 || purego
+
+package vector
+
+// no assembly for this platform (or built with -tags purego), use the portable kernels
+
+func dotKernel(a, b []float32) float32 {
+	return dotGeneric(a, b)
+}
+
+func squaredEuclideanKernel(a, b []float32) float32 {
+	return squaredEuclideanGeneric(a, b)
+}
@@ -0,0 +1,228 @@
+package vector
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// lengths around the unroll and register widths so every tail path is exercised
+var kernelTestLengths = []int{0, 1, 3, 4, 7, 8, 9, 15, 16, 31, 32, 33, 63, 64, 65, 128, 385, 1536}
+
+func randomValues(r *rand.Rand, n int) []float32 {
+	vals := make([]float32, n)
+	for i := range vals {
+		vals[i] = r.Float32()*2 - 1
+	}
+	return vals
+}
+
+// float32 accumulation drifts from the float64 reference proportionally to the magnitude of the terms
+func kernelTolerance(a, b []float32) float64 {
+	var mag float64
+	for i := range a {
+		mag += math.Abs(float64(a[i]) * float64(b[i]))
+		mag += float64(a[i])*float64(a[i]) + float64(b[i])*float64(b[i])
+	}
+	return 1e-5 * (1 + mag)
+}
+
+// Property: DotFloat32 agrees with the float64 DotProduct reference within float32 rounding.
+func TestDotFloat32_MatchesReference(t *testing.T) {
+	r := rand.New(rand.NewSource(27))
+	for _, n := range kernelTestLengths {
+		for trial := 0; trial < 20; trial++ {
+			a, b := randomValues(r, n), randomValues(r, n)
+			want, err := DotProduct(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := float64(DotFloat32(a, b))
+			if math.Abs(got-want) > kernelTolerance(a, b) {
+				t.Fatalf("len %d: DotFloat32 = %v, reference = %v", n, got, want)
+			}
+		}
+	}
+}
+
+// Property: SquaredEuclideanFloat32 agrees with -Euclidean (which returns the negated squared distance).
+func TestSquaredEuclideanFloat32_MatchesReference(t *testing.T) {
+	r := rand.New(rand.NewSource(28))
+	for _, n := range kernelTestLengths {
+		for trial := 0; trial < 20; trial++ {
+			a, b := randomValues(r, n), randomValues(r, n)
+			want, err := Euclidean(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := float64(SquaredEuclideanFloat32(a, b))
+			if math.Abs(got+want) > kernelTolerance(a, b) {
+				t.Fatalf("len %d: SquaredEuclideanFloat32 = %v, reference = %v", n, got, -want)
+			}
+		}
+	}
+}
+
+// Property: the dispatched kernels (assembly where available) agree with the portable ones.
+func TestKernels_MatchGeneric(t *testing.T) {
+	r := rand.New(rand.NewSource(29))
+	for _, n := range kernelTestLengths {
+		a, b := randomValues(r, n), randomValues(r, n)
+		tol := kernelTolerance(a, b)
+		if d := math.Abs(float64(dotKernel(a, b) - dotGeneric(a, b))); d > tol {
+			t.Errorf("len %d: dot kernel differs from generic by %v", n, d)
+		}
+		if d := math.Abs(float64(squaredEuclideanKernel(a, b) - squaredEuclideanGeneric(a, b))); d > tol {
+			t.Errorf("len %d: squared euclidean kernel differs from generic by %v", n, d)
+		}
+	}
+}
+
+// Property: batch kernels produce exactly what the single-vector kernels produce per row.
+func TestBatchKernels_MatchSingle(t *testing.T) {
+	r := rand.New(rand.NewSource(30))
+	for _, dim := range []int{1, 7, 8, 33, 128} {
+		const rows = 17
+		query := randomValues(r, dim)
+		data := randomValues(r, rows*dim)
+		dots := make([]float32, rows)
+		dists := make([]float32, rows)
+		DotBatch(query, data, dots)
+		SquaredEuclideanBatch(query, data, dists)
+		for i := 0; i < rows; i++ {
+			row := data[i*dim : (i+1)*dim]
+			if dots[i] != DotFloat32(query, row) {
+				t.Errorf("dim %d row %d: DotBatch %v != DotFloat32 %v", dim, i, dots[i], DotFloat32(query, row))
+			}
+			if dists[i] != SquaredEuclideanFloat32(query, row) {
+				t.Errorf("dim %d row %d: SquaredEuclideanBatch %v != single %v", dim, i, dists[i], SquaredEuclideanFloat32(query, row))
+			}
+		}
+	}
+}
+
+// Contract: a shorter second operand is a programming error and must panic, never read out of bounds.
+func TestDotFloat32_ShortOperandPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for len(b) < len(a)")
+		}
+	}()
+	DotFloat32(make([]float32, 16), make([]float32, 15))
+}
+
+func BenchmarkDotProduct1536(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	x, y := randomValues(r, 1536), randomValues(r, 1536)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DotProduct(x, y)
+	}
+}
+
+func BenchmarkDotFloat32_1536(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	x, y := randomValues(r, 1536), randomValues(r, 1536)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DotFloat32(x, y)
+	}
+}
+
+func BenchmarkDotBatch_1000x128(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	query, data := randomValues(r, 128), randomValues(r, 1000*128)
+	out := make([]float32, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DotBatch(query, data, out)
+	}
+}
+
+// Property: a quantized vector dequantizes to within half a quantization step per component.
+func TestQuantizeInt8_RoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(31))
+	for _, n := range kernelTestLengths[1:] {
+		vals := randomValues(r, n)
+		codes := make([]int8, n)
+		offset, scale := QuantizeInt8(vals, codes)
+		back := make([]float32, n)
+		DequantizeInt8(codes, offset, scale, back)
+		for i := range vals {
+			if d := math.Abs(float64(vals[i] - back[i])); d > float64(scale)/2+1e-6 {
+				t.Fatalf("len %d index %d: %v dequantized to %v (step %v)", n, i, vals[i], back[i], scale)
+			}
+		}
+	}
+}
+
+// Property: ScalarDot matches the exact dot product against the dequantized vector.
+func TestScalarDot_MatchesDequantized(t *testing.T) {
+	r := rand.New(rand.NewSource(32))
+	for _, n := range kernelTestLengths[1:] {
+		query, vals := randomValues(r, n), randomValues(r, n)
+		codes := make([]int8, n)
+		offset, scale := QuantizeInt8(vals, codes)
+		back := make([]float32, n)
+		DequantizeInt8(codes, offset, scale, back)
+		var qSum float32
+		for _, q := range query {
+			qSum += q
+		}
+		got := ScalarDot(query, qSum, codes, offset, scale)
+		want := DotFloat32(query, back)
+		if d := math.Abs(float64(got - want)); d > kernelTolerance(query, back)*10 {
+			t.Fatalf("len %d: ScalarDot = %v, dequantized dot = %v", n, got, want)
+		}
+	}
+}
+
+// Edge case: constant vectors have zero range and must not divide by zero.
+func TestQuantizeInt8_ConstantVector(t *testing.T) {
+	vals := []float32{0.5, 0.5, 0.5}
+	codes := make([]int8, 3)
+	offset, scale := QuantizeInt8(vals, codes)
+	back := make([]float32, 3)
+	DequantizeInt8(codes, offset, scale, back)
+	for i := range back {
+		if back[i] != 0.5 {
+			t.Fatalf("constant vector dequantized to %v", back)
+		}
+	}
+}
+
+// Property: PackSigns sets exactly the bits of positive components.
+func TestPackSigns(t *testing.T) {
+	vals := make([]float32, 70)
+	vals[0], vals[3], vals[64], vals[69] = 1, 0.5, 2, 0.1
+	vals[1] = -1
+	dst := make([]uint64, BinaryWords(len(vals)))
+	PackSigns(vals, dst)
+	if len(dst) != 2 {
+		t.Fatalf("expected 2 words for 70 dimensions, got %d", len(dst))
+	}
+	if dst[0] != 1|1<<3 || dst[1] != 1|1<<5 {
+		t.Errorf("unexpected sign bits %b %b", dst[0], dst[1])
+	}
+}
+
+// Property: hamming distance is zero for identical codes and counts each flipped sign once.
+func TestHammingDistance(t *testing.T) {
+	r := rand.New(rand.NewSource(33))
+	vals := randomValues(r, 130)
+	a := make([]uint64, BinaryWords(130))
+	PackSigns(vals, a)
+	if HammingDistance(a, a) != 0 {
+		t.Fatal("identical codes must have distance 0")
+	}
+	flipped := make([]float32, len(vals))
+	copy(flipped, vals)
+	for _, i := range []int{0, 63, 64, 129} {
+		flipped[i] = -flipped[i]
+	}
+	b := make([]uint64, len(a))
+	PackSigns(flipped, b)
+	if d := HammingDistance(a, b); d != 4 {
+		t.Errorf("expected distance 4, got %d", d)
+	}
+}
@@ -0,0 +1,180 @@
+package vector
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+// Metric is one similarity metric, everything the rest of the engine needs to know about it.
+// Scores follow one convention for every metric: higher means more similar, so distances
+// are returned negated and the index can always sort descending.
+// Adding a metric is a types.SimilarityMetric constant plus a Metric passed to RegisterMetric,
+// collection and index validation and index scoring all go through the registry.
+type Metric interface {
+	// Score of stored against query, len(stored) must be at least len(query)
+	Score(query, stored []float32) float32
+	// Normalizes reports whether vectors are scaled to unit length at construction
+	Normalizes() bool
+	// Validate rejects values outside the metric's domain, e.g. non binary values for hamming
+	Validate(values []float32) error
+}
+
+// BatchMetric is implemented by metrics with a faster path over a whole arena,
+// out[i] = Score(query, data[i*len(query):(i+1)*len(query)])
+type BatchMetric interface {
+	Metric
+	ScoreBatch(query, data []float32, out []float32)
+}
+
+var (
+	metricsMu sync.RWMutex
+	metrics   = map[types.SimilarityMetric]Metric{
+		// For current design vector values are nomalized during creation so cosine = dot
+		types.Cosine:       dotMetric{normalizes: true},
+		types.Dot:          dotMetric{normalizes: true},
+		types.Euclidean:    euclideanMetric{},
+		types.InnerProduct: dotMetric{normalizes: false},
+		types.Manhattan:    manhattanMetric{},
+		types.Hamming:      hammingMetric{},
+		types.Jaccard:      jaccardMetric{},
+	}
+)
+
+// RegisterMetric adds or replaces the implementation of a metric
+func RegisterMetric(metric types.SimilarityMetric, impl Metric) error {
+	if metric <= 0 {
+		return errors.New("invalid metric identifier")
+	}
+	if impl == nil {
+		return errors.New("nil metric implementation")
+	}
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metrics[metric] = impl
+	return nil
+}
+
+// LookupMetric returns the implementation registered for metric
+func LookupMetric(metric types.SimilarityMetric) (Metric, bool) {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	impl, ok := metrics[metric]
+	return impl, ok
+}
+
+// IsRegisteredMetric reports whether metric can be used by a collection or index
+func IsRegisteredMetric(metric types.SimilarityMetric) bool {
+	_, ok := LookupMetric(metric)
+	return ok
+}
+
+// ScoreBatch scores query against len(out) vectors stored back to back in data,
+// using the metric's batch path when it has one
+func ScoreBatch(impl Metric, query, data []float32, out []float32) {
+	if b, ok := impl.(BatchMetric); ok {
+		b.ScoreBatch(query, data, out)
+		return
+	}
+	dim := len(query)
+	_ = data[:len(out)*dim]
+	for i := range out {
+		out[i] = impl.Score(query, data[i*dim:(i+1)*dim])
+	}
+}
+
+// built-in metrics
+
+// cosine, dot and inner product, they only differ in whether vectors are normalized
+type dotMetric struct {
+	normalizes bool
+}
+
+func (m dotMetric) Score(query, stored []float32) float32 { return DotFloat32(query, stored) }
+func (m dotMetric) Normalizes() bool                      { return m.normalizes }
+func (m dotMetric) Validate(values []float32) error       { return nil }
+func (m dotMetric) ScoreBatch(query, data []float32, out []float32) {
+	DotBatch(query, data, out)
+}
+
+// negated squared L2 distance
+type euclideanMetric struct{}
+
+func (euclideanMetric) Score(query, stored []float32) float32 {
+	return -SquaredEuclideanFloat32(query, stored)
+}
+func (euclideanMetric) Normalizes() bool                { return true }
+func (euclideanMetric) Validate(values []float32) error { return nil }
+func (euclideanMetric) ScoreBatch(query, data []float32, out []float32) {
+	SquaredEuclideanBatch(query, data, out)
+	// negated squared distance so that descending order still puts the closest first
+	for i := range out {
+		out[i] = -out[i]
+	}
+}
+
+// negated L1 distance on the raw values
+type manhattanMetric struct{}
+
+func (manhattanMetric) Score(query, stored []float32) float32 {
+	stored = stored[:len(query)]
+	var sum float32
+	for i, q := range query {
+		sum += float32(math.Abs(float64(q - stored[i])))
+	}
+	return -sum
+}
+func (manhattanMetric) Normalizes() bool                { return false }
+func (manhattanMetric) Validate(values []float32) error { return nil }
+
+// negated number of differing components, values must be 0 or 1
+type hammingMetric struct{}
+
+func (hammingMetric) Score(query, stored []float32) float32 {
+	stored = stored[:len(query)]
+	diff := 0
+	for i, q := range query {
+		if q != stored[i] {
+			diff++
+		}
+	}
+	return -float32(diff)
+}
+func (hammingMetric) Normalizes() bool { return false }
+func (hammingMetric) Validate(values []float32) error {
+	for i, v := range values {
+		if v != 0 && v != 1 {
+			return errors.New("hamming vectors must be binary, invalid value at index " + strconv.Itoa(i))
+		}
+	}
+	return nil
+}
+
+// weighted jaccard similarity Σmin/Σmax, equal to |A∩B|/|A∪B| for 0/1 set vectors,
+// values must be non negative and two empty sets score 0
+type jaccardMetric struct{}
+
+func (jaccardMetric) Score(query, stored []float32) float32 {
+	stored = stored[:len(query)]
+	var inter, union float32
+	for i, q := range query {
+		inter += min(q, stored[i])
+		union += max(q, stored[i])
+	}
+	if union == 0 {
+		return 0
+	}
+	return inter / union
+}
+func (jaccardMetric) Normalizes() bool { return false }
+func (jaccardMetric) Validate(values []float32) error {
+	for i, v := range values {
+		if v < 0 {
+			return errors.New("jaccard vectors must be non negative, invalid value at index " + strconv.Itoa(i))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package vector
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func TestMetric_BuiltinScores(t *testing.T) {
+	tests := []struct {
+		metric types.SimilarityMetric
+		query  []float32
+		stored []float32
+		want   float32
+	}{
+		{types.InnerProduct, []float32{1, 2, 3}, []float32{4, 5, 6}, 32},
+		{types.Manhattan, []float32{1, 2, 3}, []float32{4, 0, 3}, -5},
+		{types.Hamming, []float32{1, 0, 1, 1}, []float32{1, 1, 0, 1}, -2},
+		{types.Jaccard, []float32{1, 1, 0, 1}, []float32{1, 0, 1, 1}, 0.5},
+		{types.Jaccard, []float32{0, 0}, []float32{0, 0}, 0},
+		{types.Euclidean, []float32{1, 0}, []float32{0, 1}, -2},
+	}
+	for _, tt := range tests {
+		impl, ok := LookupMetric(tt.metric)
+		if !ok {
+			t.Fatalf("metric %d not registered", tt.metric)
+		}
+		if got := impl.Score(tt.query, tt.stored); math.Abs(float64(got-tt.want)) > 1e-6 {
+			t.Errorf("metric %d Score(%v, %v): got %v, want %v", tt.metric, tt.query, tt.stored, got, tt.want)
+		}
+	}
+}
+
+// ScoreBatch must agree with Score whether or not the metric has a batch path
+func TestMetric_ScoreBatchMatchesScore(t *testing.T) {
+	query := []float32{1, 0, 1}
+	data := []float32{1, 1, 0, 0, 0, 1, 1, 0, 1}
+	for _, metric := range []types.SimilarityMetric{types.Cosine, types.Euclidean, types.Manhattan, types.Hamming, types.Jaccard} {
+		impl, _ := LookupMetric(metric)
+		out := make([]float32, 3)
+		ScoreBatch(impl, query, data, out)
+		for i := range out {
+			if want := impl.Score(query, data[i*3:(i+1)*3]); out[i] != want {
+				t.Errorf("metric %d row %d: batch %v, single %v", metric, i, out[i], want)
+			}
+		}
+	}
+}
+
+type constMetric struct{}
+
+func (constMetric) Score(query, stored []float32) float32 { return 7 }
+func (constMetric) Normalizes() bool                      { return false }
+func (constMetric) Validate(values []float32) error       { return nil }
+
+func TestRegisterMetric(t *testing.T) {
+	const custom types.SimilarityMetric = 1000
+	if IsRegisteredMetric(custom) {
+		t.Fatal("custom metric registered before RegisterMetric")
+	}
+	if err := RegisterMetric(custom, constMetric{}); err != nil {
+		t.Fatalf("RegisterMetric failed: %v", err)
+	}
+	t.Cleanup(func() {
+		metricsMu.Lock()
+		delete(metrics, custom)
+		metricsMu.Unlock()
+	})
+	if !IsRegisteredMetric(custom) {
+		t.Fatal("custom metric not registered")
+	}
+	if err := RegisterMetric(0, constMetric{}); err == nil {
+		t.Error("expected error for zero metric identifier")
+	}
+	if err := RegisterMetric(custom, nil); err == nil {
+		t.Error("expected error for nil implementation")
+	}
+}
+
+func TestNewVectorForMetric(t *testing.T) {
+	// non normalizing metrics keep the raw values
+	vec, err := NewVectorForMetric([]float32{3, 4}, 2, types.InnerProduct, types.Float32Precision)
+	if err != nil {
+		t.Fatalf("NewVectorForMetric failed: %v", err)
+	}
+	if vals := vec.Values(); vals[0] != 3 || vals[1] != 4 {
+		t.Errorf("inner product vector was modified: %v", vals)
+	}
+	// normalizing metrics behave like NewVector
+	vec, err = NewVectorForMetric([]float32{3, 4}, 2, types.Cosine, types.Float32Precision)
+	if err != nil {
+		t.Fatalf("NewVectorForMetric failed: %v", err)
+	}
+	if vals := vec.Values(); math.Abs(float64(vals[0])-0.6) > 1e-6 {
+		t.Errorf("cosine vector not normalized: %v", vals)
+	}
+	// domain checks
+	if _, err := NewVectorForMetric([]float32{1, 0.5}, 2, types.Hamming, types.Float32Precision); err == nil {
+		t.Error("expected error for non binary hamming vector")
+	}
+	if _, err := NewVectorForMetric([]float32{1, -1}, 2, types.Jaccard, types.Float32Precision); err == nil {
+		t.Error("expected error for negative jaccard vector")
+	}
+	if _, err := NewVectorForMetric([]float32{1, 0}, 2, types.SimilarityMetric(99), types.Float32Precision); err == nil {
+		t.Error("expected error for unregistered metric")
+	}
+	// raw values past float16 range cannot be stored in half precision
+	if _, err := NewVectorForMetric([]float32{1e6, 1}, 2, types.Manhattan, types.Float16Precision); err == nil {
+		t.Error("expected error for value out of float16 range")
+	}
+}
@@ -0,0 +1,87 @@
+package vector
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+// MultiVector is a variable length bag of token vectors of one dimension, the point type of
+// late interaction (ColBERT style) retrieval. Like Vector it is immutable, rows are stored
+// back to back in one slice and every row passed the same constructor a single Vector would.
+type MultiVector struct {
+	data       []float32
+	rows       int
+	dimensions int
+}
+
+// NewMultiVector builds each row the way NewVectorForMetric would and keeps them in order
+func NewMultiVector(rows [][]float32, dim int, metric types.SimilarityMetric, prec types.Precision) (*MultiVector, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("a multi vector must have atleast one vector")
+	}
+	mv := &MultiVector{
+		data:       make([]float32, 0, len(rows)*dim),
+		rows:       len(rows),
+		dimensions: dim,
+	}
+	for i, row := range rows {
+		vec, err := NewVectorForMetric(row, dim, metric, prec)
+		if err != nil {
+			return nil, errors.New("multi vector row " + strconv.Itoa(i) + ": " + err.Error())
+		}
+		mv.data = append(mv.data, vec.values...)
+	}
+	return mv, nil
+}
+
+// multi vector api
+func (mv *MultiVector) Dimensions() int {
+	return mv.dimensions
+}
+
+// number of token vectors
+func (mv *MultiVector) Len() int {
+	return mv.rows
+}
+
+// Rows returns a copy of every token vector
+func (mv *MultiVector) Rows() [][]float32 {
+	rows := make([][]float32, mv.rows)
+	for i := range rows {
+		rows[i] = make([]float32, mv.dimensions)
+		copy(rows[i], mv.row(i))
+	}
+	return rows
+}
+
+// Vectors returns the token vectors as Vectors, e.g. to index them one by one
+func (mv *MultiVector) Vectors() []*Vector {
+	vecs := make([]*Vector, mv.rows)
+	for i := range vecs {
+		vecs[i] = FromNormalized(mv.row(i))
+	}
+	return vecs
+}
+
+// view of one row, not a copy
+func (mv *MultiVector) row(i int) []float32 {
+	return mv.data[i*mv.dimensions : (i+1)*mv.dimensions : (i+1)*mv.dimensions]
+}
+
+// MaxSim is the late interaction score of a document against a query: for every query token the
+// best score over all document tokens under the metric, summed over the query tokens
+func MaxSim(query, doc *MultiVector, impl Metric) float32 {
+	scores := make([]float32, doc.rows)
+	var total float32
+	for i := range query.rows {
+		ScoreBatch(impl, query.row(i), doc.data, scores)
+		best := scores[0]
+		for _, s := range scores[1:] {
+			best = max(best, s)
+		}
+		total += best
+	}
+	return total
+}
@@ -0,0 +1,50 @@
+package vector
+
+import (
+	"testing"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
+
+func TestNewMultiVector(t *testing.T) {
+	if _, err := NewMultiVector(nil, 2, types.Dot, types.Float32Precision); err == nil {
+		t.Error("Expected error for an empty multi vector")
+	}
+	if _, err := NewMultiVector([][]float32{{1, 0}, {1, 0, 0}}, 2, types.Dot, types.Float32Precision); err == nil {
+		t.Error("Expected error for a row of the wrong dimension")
+	}
+	mv, err := NewMultiVector([][]float32{{3, 4}, {0, 2}}, 2, types.Cosine, types.Float32Precision)
+	if err != nil {
+		t.Fatalf("NewMultiVector failed: %v", err)
+	}
+	if mv.Len() != 2 || mv.Dimensions() != 2 {
+		t.Fatalf("expected 2 rows of 2, got %d rows of %d", mv.Len(), mv.Dimensions())
+	}
+	// cosine rows are normalized one by one
+	rows := mv.Rows()
+	if rows[0][0] != 0.6 || rows[0][1] != 0.8 || rows[1][0] != 0 || rows[1][1] != 1 {
+		t.Errorf("unexpected rows %v", rows)
+	}
+	// Rows hands out copies
+	rows[0][0] = 42
+	if mv.Rows()[0][0] != 0.6 {
+		t.Error("mutating Rows changed the multi vector")
+	}
+}
+
+func TestMaxSim(t *testing.T) {
+	impl, _ := LookupMetric(types.InnerProduct)
+	doc, _ := NewMultiVector([][]float32{{1, 0}, {0, 2}}, 2, types.InnerProduct, types.Float32Precision)
+	query, _ := NewMultiVector([][]float32{{1, 0}, {0, 1}, {1, 1}}, 2, types.InnerProduct, types.Float32Precision)
+	// best per query token: 1 (row 0), 2 (row 1), 2 (row 1)
+	if got := MaxSim(query, doc, impl); got != 5 {
+		t.Errorf("expected MaxSim 5, got %v", got)
+	}
+	// distances are negated, the closest row wins per query token
+	impl, _ = LookupMetric(types.Euclidean)
+	doc, _ = NewMultiVector([][]float32{{1, 0}, {0, 1}}, 2, types.Euclidean, types.Float32Precision)
+	query, _ = NewMultiVector([][]float32{{1, 0}, {0, 1}}, 2, types.Euclidean, types.Float32Precision)
+	if got := MaxSim(query, doc, impl); got != 0 {
+		t.Errorf("expected MaxSim 0 for matching tokens, got %v", got)
+	}
+}
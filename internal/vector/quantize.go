@@ -0,0 +1,67 @@
+package vector
+
+import "math"
+
+// Scalar (int8) quantization helpers
+// a vector is mapped affinely onto the int8 range with its own offset and scale:
+//
+//	x[i] ≈ offset + scale*(code[i]+128)
+//
+// per-vector parameters need no training pass over the data, which suits an index
+// that receives vectors one at a time.
+
+// QuantizeInt8 writes the codes of values into dst (len(dst) >= len(values)) and returns offset and scale
+func QuantizeInt8(values []float32, dst []int8) (offset, scale float32) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	lo, hi := values[0], values[0]
+	for _, x := range values[1:] {
+		lo = min(lo, x)
+		hi = max(hi, x)
+	}
+	offset = lo
+	if hi == lo {
+		// constant vector, every code maps exactly onto the offset
+		for i := range values {
+			dst[i] = -128
+		}
+		return offset, 0
+	}
+	scale = (hi - lo) / 255
+	for i, x := range values {
+		code := math.Round(float64((x-lo)/scale)) - 128
+		dst[i] = int8(max(-128, min(127, code)))
+	}
+	return offset, scale
+}
+
+// DequantizeInt8 is the inverse of QuantizeInt8 up to rounding, dst must hold len(codes) values
+func DequantizeInt8(codes []int8, offset, scale float32, dst []float32) {
+	for i, c := range codes {
+		dst[i] = offset + scale*(float32(c)+128)
+	}
+}
+
+// DotInt8 returns query·codes treating the codes as plain numbers, len(codes) must be at least len(query)
+// the caller folds in offset and scale, see ScalarDot
+func DotInt8(query []float32, codes []int8) float32 {
+	codes = codes[:len(query)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(query); i += 4 {
+		s0 += query[i] * float32(codes[i])
+		s1 += query[i+1] * float32(codes[i+1])
+		s2 += query[i+2] * float32(codes[i+2])
+		s3 += query[i+3] * float32(codes[i+3])
+	}
+	for ; i < len(query); i++ {
+		s0 += query[i] * float32(codes[i])
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// ScalarDot approximates query·x for a quantized x, querySum is the sum of the query components
+func ScalarDot(query []float32, querySum float32, codes []int8, offset, scale float32) float32 {
+	return offset*querySum + scale*(DotInt8(query, codes)+128*querySum)
+}
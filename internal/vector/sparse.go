@@ -0,0 +1,108 @@
+package vector
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strconv"
+)
+
+// SparseVector is the sparse counterpart of Vector, non zero components as index/value pairs
+// over a vocabulary of dimensions entries. Like Vector it is an immutable data object,
+// indices are kept sorted ascending so two vectors can be intersected in one merge pass.
+type SparseVector struct {
+	indices    []uint32
+	values     []float32
+	dimensions int
+}
+
+// NewSparseVector validates and sorts the pairs, explicit zeros are dropped
+// vocabulary is the exclusive upper bound for indices
+func NewSparseVector(indices []uint32, values []float32, vocabulary int) (*SparseVector, error) {
+	if vocabulary <= 0 {
+		return nil, errors.New("sparse vector vocabulary must be positive")
+	}
+	if len(indices) != len(values) {
+		return nil, errors.New("sparse vector indices and values length mismatch")
+	}
+	if err := validateValues(values); err != nil {
+		return nil, err
+	}
+	order := make([]int, 0, len(indices))
+	for i, idx := range indices {
+		if int64(idx) >= int64(vocabulary) {
+			return nil, errors.New("sparse vector index out of vocabulary range at position " + strconv.Itoa(i))
+		}
+		if values[i] != 0 {
+			order = append(order, i)
+		}
+	}
+	if len(order) == 0 {
+		return nil, errors.New("a sparse vector must have atleast one non zero value")
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp.Compare(indices[a], indices[b])
+	})
+	sv := &SparseVector{
+		indices:    make([]uint32, len(order)),
+		values:     make([]float32, len(order)),
+		dimensions: vocabulary,
+	}
+	for i, pos := range order {
+		if i > 0 && indices[pos] == sv.indices[i-1] {
+			return nil, errors.New("duplicate sparse vector index " + strconv.FormatUint(uint64(indices[pos]), 10))
+		}
+		sv.indices[i] = indices[pos]
+		sv.values[i] = values[pos]
+	}
+	return sv, nil
+}
+
+// NewNormalizedSparseVector is NewSparseVector scaled to unit length, used for cosine and dot collections
+func NewNormalizedSparseVector(indices []uint32, values []float32, vocabulary int) (*SparseVector, error) {
+	sv, err := NewSparseVector(indices, values, vocabulary)
+	if err != nil {
+		return nil, err
+	}
+	norm, err := Normalize(sv.values)
+	if err != nil {
+		return nil, err
+	}
+	sv.values = norm
+	return sv, nil
+}
+
+// sparse vector api
+func (sv *SparseVector) Dimensions() int {
+	return sv.dimensions
+}
+
+// number of non zero components
+func (sv *SparseVector) Len() int {
+	return len(sv.indices)
+}
+func (sv *SparseVector) Indices() []uint32 {
+	return slices.Clone(sv.indices)
+}
+func (sv *SparseVector) Values() []float32 {
+	return slices.Clone(sv.values)
+}
+
+// SparseDot is the dot product of two sparse vectors, a merge over the sorted indices
+func SparseDot(a, b *SparseVector) float32 {
+	var sum float32
+	i, j := 0, 0
+	for i < len(a.indices) && j < len(b.indices) {
+		switch {
+		case a.indices[i] == b.indices[j]:
+			sum += a.values[i] * b.values[j]
+			i++
+			j++
+		case a.indices[i] < b.indices[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return sum
+}
@@ -0,0 +1,51 @@
+package vector
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestNewSparseVector(t *testing.T) {
+	sv, err := NewSparseVector([]uint32{9, 2, 5}, []float32{1, 3, 0}, 10)
+	if err != nil {
+		t.Fatalf("NewSparseVector failed: %v", err)
+	}
+	// sorted by index, explicit zero dropped
+	if !slices.Equal(sv.Indices(), []uint32{2, 9}) || !slices.Equal(sv.Values(), []float32{3, 1}) {
+		t.Errorf("unexpected pairs %v %v", sv.Indices(), sv.Values())
+	}
+	if sv.Len() != 2 || sv.Dimensions() != 10 {
+		t.Errorf("expected len 2 dim 10, got %d %d", sv.Len(), sv.Dimensions())
+	}
+
+	invalid := []struct {
+		name    string
+		indices []uint32
+		values  []float32
+	}{
+		{"LengthMismatch", []uint32{1, 2}, []float32{1}},
+		{"OutOfRange", []uint32{10}, []float32{1}},
+		{"Duplicate", []uint32{4, 4}, []float32{1, 2}},
+		{"AllZero", []uint32{1}, []float32{0}},
+		{"Empty", nil, nil},
+		{"NaN", []uint32{1}, []float32{float32(math.NaN())}},
+	}
+	for _, tt := range invalid {
+		if _, err := NewSparseVector(tt.indices, tt.values, 10); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestSparseDot(t *testing.T) {
+	a, _ := NewSparseVector([]uint32{1, 4, 7}, []float32{1, 2, 3}, 10)
+	b, _ := NewSparseVector([]uint32{0, 4, 7, 9}, []float32{5, 0.5, 2, 1}, 10)
+	if got := SparseDot(a, b); got != 7 {
+		t.Errorf("expected 7, got %v", got)
+	}
+	n, _ := NewNormalizedSparseVector([]uint32{1, 2}, []float32{3, 4}, 10)
+	if got := SparseDot(n, n); math.Abs(float64(got)-1) > 1e-6 {
+		t.Errorf("normalized self dot should be 1, got %v", got)
+	}
+}
@@ -1,6 +1,10 @@
 package vector
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/Kasbe14/Dattaniddhi/internal/types"
+)
 
 //vector is pure data object
 type Vector struct {
@@ -33,6 +37,64 @@ func NewVector(vecValues []float32, dim int) (*Vector, error) {
 	return vec, nil
 }
 
+// NewVectorWithPrecision is NewVector followed by rounding the normalized values to prec,
+// so the vector already holds exactly what a half precision index or WAL will store
+func NewVectorWithPrecision(vecValues []float32, dim int, prec types.Precision) (*Vector, error) {
+	switch prec {
+	case types.Float32Precision, types.Float16Precision, types.BFloat16Precision:
+		//ok valid input
+	default:
+		return nil, errors.New("invalid vector precision")
+	}
+	vec, err := NewVector(vecValues, dim)
+	if err != nil {
+		return nil, err
+	}
+	RoundToPrecision(vec.values, prec)
+	return vec, nil
+}
+
+// NewVectorForMetric builds the vector the way metric expects it: values are checked against
+// the metric's domain, normalized only when the metric works on unit vectors and rounded to prec
+func NewVectorForMetric(vecValues []float32, dim int, metric types.SimilarityMetric, prec types.Precision) (*Vector, error) {
+	impl, ok := LookupMetric(metric)
+	if !ok {
+		return nil, errors.New("invalid similarity metric")
+	}
+	if impl.Normalizes() {
+		return NewVectorWithPrecision(vecValues, dim, prec)
+	}
+	switch prec {
+	case types.Float32Precision, types.Float16Precision, types.BFloat16Precision:
+		//ok valid input
+	default:
+		return nil, errors.New("invalid vector precision")
+	}
+	if len(vecValues) == 0 {
+		return nil, errors.New("a vector must have atleast one dimension")
+	}
+	if len(vecValues) != dim {
+		return nil, errors.New("invalid dimension mismatch")
+	}
+	if err := validateValues(vecValues); err != nil {
+		return nil, err
+	}
+	if err := impl.Validate(vecValues); err != nil {
+		return nil, err
+	}
+	vecVals := make([]float32, dim)
+	copy(vecVals, vecValues)
+	RoundToPrecision(vecVals, prec)
+	// raw values are not bounded by normalization and may not fit a half precision type
+	if err := validateValues(vecVals); err != nil {
+		return nil, errors.New("vector value out of range for precision")
+	}
+	return &Vector{
+		values:     vecVals,
+		dimensions: dim,
+	}, nil
+}
+
 //vector api
 func (v *Vector) Dimensions() int {
 	return v.dimensions
@@ -42,3 +104,19 @@ func (v *Vector) Values() []float32 {
 	copy(vecVals, v.values)
 	return vecVals
 }
+
+// FromNormalized rebuilds a vector from values that already passed one of the constructors,
+// storage layers use it to hand back vectors without normalizing them twice
+func FromNormalized(values []float32) *Vector {
+	vecVals := make([]float32, len(values))
+	copy(vecVals, values)
+	return &Vector{
+		values:     vecVals,
+		dimensions: len(vecVals),
+	}
+}
+
+// CopyValues copies the vector values into dst without allocating and returns the number copied
+func (v *Vector) CopyValues(dst []float32) int {
+	return copy(dst, v.values)
+}